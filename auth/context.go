@@ -0,0 +1,29 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+)
+
+type contextKey string
+
+const principalContextKey = contextKey("principal")
+
+func ContextSetPrincipal(r *http.Request, principal *Principal) *http.Request {
+	ctx := context.WithValue(r.Context(), principalContextKey, principal)
+	return r.WithContext(ctx)
+}
+
+func ContextGetPrincipal(r *http.Request) *Principal {
+	principal, ok := PrincipalFromContext(r.Context())
+	if !ok {
+		panic("missing principal value in request context")
+	}
+
+	return principal
+}
+
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalContextKey).(*Principal)
+	return principal, ok
+}
@@ -0,0 +1,251 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/leninner/shared/config"
+)
+
+var (
+	ErrInvalidToken         = errors.New("auth: invalid token")
+	ErrInvalidSignature     = errors.New("auth: invalid token signature")
+	ErrExpiredToken         = errors.New("auth: token has expired")
+	ErrTokenNotYetValid     = errors.New("auth: token is not valid yet")
+	ErrInvalidIssuer        = errors.New("auth: unexpected token issuer")
+	ErrInvalidAudience      = errors.New("auth: unexpected token audience")
+	ErrUnsupportedAlgorithm = errors.New("auth: unsupported signing algorithm")
+	ErrUnknownKey           = errors.New("auth: unknown signing key")
+)
+
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// Audience accepts both the string and the array form of the aud claim
+type Audience []string
+
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+
+	*a = multiple
+	return nil
+}
+
+func (a Audience) Contains(audience string) bool {
+	return slices.Contains(a, audience)
+}
+
+// Claims holds the registered claims checked by the Verifier plus the
+// account state claims used by the middleware
+type Claims struct {
+	Issuer      string      `json:"iss"`
+	Subject     string      `json:"sub"`
+	Audience    Audience    `json:"aud"`
+	ExpiresAt   int64       `json:"exp"`
+	NotBefore   int64       `json:"nbf"`
+	IssuedAt    int64       `json:"iat"`
+	Activated   bool        `json:"activated"`
+	Roles       []string    `json:"roles"`
+	Permissions Permissions `json:"permissions"`
+}
+
+type header struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+	Type      string `json:"typ"`
+}
+
+type Verifier struct {
+	keys       KeySet
+	issuer     string
+	audience   string
+	algorithms []string
+	leeway     time.Duration
+	now        func() time.Time
+}
+
+// NewVerifier builds a Verifier from the auth configuration. A JWKS file takes
+// precedence over a shared secret.
+func NewVerifier(cfg config.AuthConfig) (*Verifier, error) {
+	var keys KeySet
+
+	switch {
+	case cfg.JWKSFile != "":
+		jwks, err := NewJWKSFile(cfg.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		keys = jwks
+	case cfg.Secret != "":
+		keys = NewSecretKeySet([]byte(cfg.Secret))
+	default:
+		return nil, errors.New("auth: either a JWKS file or a secret must be configured")
+	}
+
+	return NewVerifierWithKeys(keys, cfg), nil
+}
+
+func NewVerifierWithKeys(keys KeySet, cfg config.AuthConfig) *Verifier {
+	algorithms := cfg.Algorithms
+	if len(algorithms) == 0 {
+		algorithms = []string{AlgorithmHS256, AlgorithmRS256, AlgorithmEdDSA}
+	}
+
+	return &Verifier{
+		keys:       keys,
+		issuer:     cfg.Issuer,
+		audience:   cfg.Audience,
+		algorithms: algorithms,
+		leeway:     cfg.Leeway,
+		now:        time.Now,
+	}
+}
+
+// Verify checks the token signature, expiry, issuer and audience and returns
+// its claims
+func (v *Verifier) Verify(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if !slices.Contains(v.algorithms, h.Algorithm) {
+		return nil, ErrUnsupportedAlgorithm
+	}
+
+	key, err := v.keys.Key(h.KeyID, h.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	err = verifySignature(h.Algorithm, key, []byte(parts[0]+"."+parts[1]), signature)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if err := v.validateClaims(&claims); err != nil {
+		return nil, err
+	}
+
+	return &claims, nil
+}
+
+// Authenticate verifies the token and returns the principal it identifies
+func (v *Verifier) Authenticate(token string) (*Principal, error) {
+	claims, err := v.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewPrincipalFromClaims(claims)
+}
+
+func (v *Verifier) validateClaims(claims *Claims) error {
+	now := v.now()
+
+	if claims.ExpiresAt == 0 || now.After(time.Unix(claims.ExpiresAt, 0).Add(v.leeway)) {
+		return ErrExpiredToken
+	}
+
+	if claims.NotBefore != 0 && now.Add(v.leeway).Before(time.Unix(claims.NotBefore, 0)) {
+		return ErrTokenNotYetValid
+	}
+
+	if claims.IssuedAt != 0 && now.Add(v.leeway).Before(time.Unix(claims.IssuedAt, 0)) {
+		return ErrTokenNotYetValid
+	}
+
+	if v.issuer != "" && claims.Issuer != v.issuer {
+		return ErrInvalidIssuer
+	}
+
+	if v.audience != "" && !claims.Audience.Contains(v.audience) {
+		return ErrInvalidAudience
+	}
+
+	return nil
+}
+
+func verifySignature(algorithm string, key any, signingInput, signature []byte) error {
+	switch algorithm {
+	case AlgorithmHS256:
+		secret, ok := key.([]byte)
+		if !ok {
+			return ErrUnknownKey
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(signingInput)
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return ErrInvalidSignature
+		}
+
+	case AlgorithmRS256:
+		publicKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return ErrUnknownKey
+		}
+		digest := sha256.Sum256(signingInput)
+		if rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, digest[:], signature) != nil {
+			return ErrInvalidSignature
+		}
+
+	case AlgorithmEdDSA:
+		publicKey, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return ErrUnknownKey
+		}
+		if !ed25519.Verify(publicKey, signingInput, signature) {
+			return ErrInvalidSignature
+		}
+
+	default:
+		return ErrUnsupportedAlgorithm
+	}
+
+	return nil
+}
+
+func decodeSegment(segment string, target any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, target)
+}
@@ -0,0 +1,500 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/leninner/shared/config"
+)
+
+const testSubject = "5f0c6a3e-8a47-4d2e-9d8b-1f3c2a6e7b90"
+
+var testNow = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+type testKeys struct {
+	secret     []byte
+	rsa        *rsa.PrivateKey
+	rsaOther   *rsa.PrivateKey
+	ed25519    ed25519.PrivateKey
+	ed25519Pub ed25519.PublicKey
+}
+
+func newTestKeys(t *testing.T) testKeys {
+	t.Helper()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaOther, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return testKeys{
+		secret:     []byte("a-shared-secret-of-sufficient-length"),
+		rsa:        rsaKey,
+		rsaOther:   rsaOther,
+		ed25519:    edKey,
+		ed25519Pub: edPub,
+	}
+}
+
+func segment(t *testing.T, value any) string {
+	t.Helper()
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// signToken builds a compact JWS. key is a []byte secret for HS256, an
+// *rsa.PrivateKey for RS256 and an ed25519.PrivateKey for EdDSA.
+func signToken(t *testing.T, alg, kid string, key any, claims map[string]any) string {
+	t.Helper()
+
+	h := map[string]any{"alg": alg, "typ": "JWT"}
+	if kid != "" {
+		h["kid"] = kid
+	}
+	signingInput := segment(t, h) + "." + segment(t, claims)
+
+	var signature []byte
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signingInput))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		digest := sha256.Sum256([]byte(signingInput))
+		var err error
+		signature, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+	case ed25519.PrivateKey:
+		signature = ed25519.Sign(k, []byte(signingInput))
+	case nil:
+	default:
+		t.Fatalf("unsupported signing key %T", key)
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func validClaims() map[string]any {
+	return map[string]any{
+		"sub": testSubject,
+		"iss": "https://auth.taneats.test",
+		"aud": "orders",
+		"exp": testNow.Add(time.Hour).Unix(),
+		"iat": testNow.Add(-time.Minute).Unix(),
+	}
+}
+
+func withClaims(overrides map[string]any) map[string]any {
+	claims := validClaims()
+	for key, value := range overrides {
+		if value == nil {
+			delete(claims, key)
+			continue
+		}
+		claims[key] = value
+	}
+	return claims
+}
+
+func rsaJWK(kid string, key *rsa.PublicKey) map[string]any {
+	return map[string]any{
+		"kty": "RSA",
+		"kid": kid,
+		"use": "sig",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func ed25519JWK(kid string, key ed25519.PublicKey) map[string]any {
+	return map[string]any{
+		"kty": "OKP",
+		"crv": "Ed25519",
+		"kid": kid,
+		"x":   base64.RawURLEncoding.EncodeToString(key),
+	}
+}
+
+func writeJWKS(t *testing.T, path string, keys ...map[string]any) {
+	t.Helper()
+
+	data, err := json.Marshal(map[string]any{"keys": keys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newTestVerifier(keys KeySet, cfg config.AuthConfig) *Verifier {
+	v := NewVerifierWithKeys(keys, cfg)
+	v.now = func() time.Time { return testNow }
+	return v
+}
+
+// staticKeySet returns the same key whatever the header asks for, so the
+// signature check itself must reject keys of the wrong type
+type staticKeySet struct {
+	key any
+}
+
+func (s staticKeySet) Key(keyID, algorithm string) (any, error) {
+	return s.key, nil
+}
+
+func TestVerify(t *testing.T) {
+	keys := newTestKeys(t)
+
+	jwksPath := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, jwksPath,
+		rsaJWK("rsa-1", &keys.rsa.PublicKey),
+		rsaJWK("rsa-2", &keys.rsaOther.PublicKey),
+		ed25519JWK("ed-1", keys.ed25519Pub),
+	)
+	jwks, err := NewJWKSFile(jwksPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rsaOnlyPath := filepath.Join(t.TempDir(), "rsa.json")
+	writeJWKS(t, rsaOnlyPath, rsaJWK("rsa-1", &keys.rsa.PublicKey))
+	rsaOnly, err := NewJWKSFile(rsaOnlyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edOnlyPath := filepath.Join(t.TempDir(), "ed.json")
+	writeJWKS(t, edOnlyPath, ed25519JWK("ed-1", keys.ed25519Pub))
+	edOnly, err := NewJWKSFile(edOnlyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rsaPublicDER, err := x509.MarshalPKIXPublicKey(&keys.rsa.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secret := NewSecretKeySet(keys.secret)
+	cfg := config.AuthConfig{
+		Issuer:   "https://auth.taneats.test",
+		Audience: "orders",
+		Leeway:   30 * time.Second,
+	}
+
+	tests := []struct {
+		name    string
+		keys    KeySet
+		cfg     *config.AuthConfig
+		token   string
+		wantErr error
+	}{
+		{
+			name:  "valid HS256",
+			keys:  secret,
+			token: signToken(t, AlgorithmHS256, "", keys.secret, validClaims()),
+		},
+		{
+			name:  "valid RS256 selected by kid",
+			keys:  jwks,
+			token: signToken(t, AlgorithmRS256, "rsa-2", keys.rsaOther, validClaims()),
+		},
+		{
+			name:  "valid EdDSA selected by kid",
+			keys:  jwks,
+			token: signToken(t, AlgorithmEdDSA, "ed-1", keys.ed25519, validClaims()),
+		},
+		{
+			name:  "no kid with a single matching key",
+			keys:  rsaOnly,
+			token: signToken(t, AlgorithmRS256, "", keys.rsa, validClaims()),
+		},
+		{
+			name:    "no kid with several matching keys",
+			keys:    jwks,
+			token:   signToken(t, AlgorithmRS256, "", keys.rsa, validClaims()),
+			wantErr: ErrUnknownKey,
+		},
+		{
+			name:    "kid of another key",
+			keys:    jwks,
+			token:   signToken(t, AlgorithmRS256, "rsa-1", keys.rsaOther, validClaims()),
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "unknown kid",
+			keys:    jwks,
+			token:   signToken(t, AlgorithmRS256, "rsa-3", keys.rsa, validClaims()),
+			wantErr: ErrUnknownKey,
+		},
+		{
+			name:    "kid of a key for another algorithm",
+			keys:    jwks,
+			token:   signToken(t, AlgorithmRS256, "ed-1", keys.rsa, validClaims()),
+			wantErr: ErrUnknownKey,
+		},
+		{
+			name:    "HS256 token against an RSA key set",
+			keys:    rsaOnly,
+			token:   signToken(t, AlgorithmHS256, "rsa-1", rsaPublicDER, validClaims()),
+			wantErr: ErrUnknownKey,
+		},
+		{
+			name:    "HS256 token against an Ed25519 key set",
+			keys:    edOnly,
+			token:   signToken(t, AlgorithmHS256, "ed-1", []byte(keys.ed25519Pub), validClaims()),
+			wantErr: ErrUnknownKey,
+		},
+		{
+			name:    "HS256 token signed with the RSA public key",
+			keys:    staticKeySet{key: &keys.rsa.PublicKey},
+			token:   signToken(t, AlgorithmHS256, "", rsaPublicDER, validClaims()),
+			wantErr: ErrUnknownKey,
+		},
+		{
+			name:    "HS256 token signed with the Ed25519 public key",
+			keys:    staticKeySet{key: keys.ed25519Pub},
+			token:   signToken(t, AlgorithmHS256, "", []byte(keys.ed25519Pub), validClaims()),
+			wantErr: ErrUnknownKey,
+		},
+		{
+			name:    "RS256 token against an Ed25519 key",
+			keys:    staticKeySet{key: keys.ed25519Pub},
+			token:   signToken(t, AlgorithmRS256, "", keys.rsa, validClaims()),
+			wantErr: ErrUnknownKey,
+		},
+		{
+			name:    "RS256 token against a shared secret",
+			keys:    secret,
+			token:   signToken(t, AlgorithmRS256, "", keys.rsa, validClaims()),
+			wantErr: ErrUnknownKey,
+		},
+		{
+			name:    "alg none",
+			keys:    secret,
+			token:   signToken(t, "none", "", nil, validClaims()),
+			wantErr: ErrUnsupportedAlgorithm,
+		},
+		{
+			name:    "algorithm not accepted by the configuration",
+			keys:    jwks,
+			cfg:     &config.AuthConfig{Algorithms: []string{AlgorithmHS256}},
+			token:   signToken(t, AlgorithmRS256, "rsa-1", keys.rsa, validClaims()),
+			wantErr: ErrUnsupportedAlgorithm,
+		},
+		{
+			name:    "bad signature",
+			keys:    secret,
+			token:   signToken(t, AlgorithmHS256, "", []byte("another-secret"), validClaims()),
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "malformed token",
+			keys:    secret,
+			token:   "not.a-token",
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "missing exp",
+			keys:    secret,
+			token:   signToken(t, AlgorithmHS256, "", keys.secret, withClaims(map[string]any{"exp": nil})),
+			wantErr: ErrExpiredToken,
+		},
+		{
+			name:    "expired",
+			keys:    secret,
+			token:   signToken(t, AlgorithmHS256, "", keys.secret, withClaims(map[string]any{"exp": testNow.Add(-time.Minute).Unix()})),
+			wantErr: ErrExpiredToken,
+		},
+		{
+			name:  "expired within the leeway",
+			keys:  secret,
+			token: signToken(t, AlgorithmHS256, "", keys.secret, withClaims(map[string]any{"exp": testNow.Add(-10 * time.Second).Unix()})),
+		},
+		{
+			name:    "nbf in the future",
+			keys:    secret,
+			token:   signToken(t, AlgorithmHS256, "", keys.secret, withClaims(map[string]any{"nbf": testNow.Add(time.Minute).Unix()})),
+			wantErr: ErrTokenNotYetValid,
+		},
+		{
+			name:  "nbf within the leeway",
+			keys:  secret,
+			token: signToken(t, AlgorithmHS256, "", keys.secret, withClaims(map[string]any{"nbf": testNow.Add(10 * time.Second).Unix()})),
+		},
+		{
+			name:    "iat in the future",
+			keys:    secret,
+			token:   signToken(t, AlgorithmHS256, "", keys.secret, withClaims(map[string]any{"iat": testNow.Add(time.Minute).Unix()})),
+			wantErr: ErrTokenNotYetValid,
+		},
+		{
+			name:    "wrong issuer",
+			keys:    secret,
+			token:   signToken(t, AlgorithmHS256, "", keys.secret, withClaims(map[string]any{"iss": "https://evil.test"})),
+			wantErr: ErrInvalidIssuer,
+		},
+		{
+			name:    "missing issuer",
+			keys:    secret,
+			token:   signToken(t, AlgorithmHS256, "", keys.secret, withClaims(map[string]any{"iss": nil})),
+			wantErr: ErrInvalidIssuer,
+		},
+		{
+			name:  "audience array containing the audience",
+			keys:  secret,
+			token: signToken(t, AlgorithmHS256, "", keys.secret, withClaims(map[string]any{"aud": []string{"payments", "orders"}})),
+		},
+		{
+			name:    "audience string for another service",
+			keys:    secret,
+			token:   signToken(t, AlgorithmHS256, "", keys.secret, withClaims(map[string]any{"aud": "payments"})),
+			wantErr: ErrInvalidAudience,
+		},
+		{
+			name:    "audience array without the audience",
+			keys:    secret,
+			token:   signToken(t, AlgorithmHS256, "", keys.secret, withClaims(map[string]any{"aud": []string{"payments", "restaurants"}})),
+			wantErr: ErrInvalidAudience,
+		},
+		{
+			name:    "missing audience",
+			keys:    secret,
+			token:   signToken(t, AlgorithmHS256, "", keys.secret, withClaims(map[string]any{"aud": nil})),
+			wantErr: ErrInvalidAudience,
+		},
+		{
+			name:    "audience of the wrong type",
+			keys:    secret,
+			token:   signToken(t, AlgorithmHS256, "", keys.secret, withClaims(map[string]any{"aud": 42})),
+			wantErr: ErrInvalidToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := cfg
+			if tt.cfg != nil {
+				c = *tt.cfg
+			}
+
+			claims, err := newTestVerifier(tt.keys, c).Verify(tt.token)
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if claims.Subject != testSubject {
+				t.Errorf("got subject %q, want %q", claims.Subject, testSubject)
+			}
+		})
+	}
+}
+
+func TestJWKSFileReloadsWhenModified(t *testing.T) {
+	keys := newTestKeys(t)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, path, rsaJWK("rsa-1", &keys.rsa.PublicKey))
+
+	jwks, err := NewJWKSFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier := newTestVerifier(jwks, config.AuthConfig{})
+
+	rotated := signToken(t, AlgorithmRS256, "rsa-2", keys.rsaOther, validClaims())
+
+	if _, err := verifier.Verify(rotated); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("before rotation: got error %v, want %v", err, ErrUnknownKey)
+	}
+
+	writeJWKS(t, path, rsaJWK("rsa-2", &keys.rsaOther.PublicKey))
+	modified := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, modified, modified); err != nil {
+		t.Fatal(err)
+	}
+
+	// The file is not checked again until the interval has passed
+	if _, err := verifier.Verify(rotated); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("within the check interval: got error %v, want %v", err, ErrUnknownKey)
+	}
+
+	jwks.mu.Lock()
+	jwks.checkedAt = time.Time{}
+	jwks.mu.Unlock()
+
+	if _, err := verifier.Verify(rotated); err != nil {
+		t.Fatalf("after rotation: unexpected error %v", err)
+	}
+
+	old := signToken(t, AlgorithmRS256, "rsa-1", keys.rsa, validClaims())
+	if _, err := verifier.Verify(old); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("retired key: got error %v, want %v", err, ErrUnknownKey)
+	}
+}
+
+func TestJWKSFileKeepsKeysWhenUnchanged(t *testing.T) {
+	keys := newTestKeys(t)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, path, rsaJWK("rsa-1", &keys.rsa.PublicKey))
+
+	jwks, err := NewJWKSFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Same modification time: the new content must not be picked up
+	writeJWKS(t, path, rsaJWK("rsa-2", &keys.rsaOther.PublicKey))
+	if err := os.Chtimes(path, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := jwks.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := jwks.Key("rsa-1", AlgorithmRS256); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
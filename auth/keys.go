@@ -0,0 +1,227 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+)
+
+// KeySet resolves the verification key for a token header
+type KeySet interface {
+	Key(keyID, algorithm string) (any, error)
+}
+
+type SecretKeySet struct {
+	secret []byte
+}
+
+func NewSecretKeySet(secret []byte) *SecretKeySet {
+	return &SecretKeySet{secret: secret}
+}
+
+func (s *SecretKeySet) Key(keyID, algorithm string) (any, error) {
+	if algorithm != AlgorithmHS256 {
+		return nil, ErrUnknownKey
+	}
+
+	return s.secret, nil
+}
+
+type jsonWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	Curve     string `json:"crv"`
+	K         string `json:"k"`
+	N         string `json:"n"`
+	E         string `json:"e"`
+	X         string `json:"x"`
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+type verificationKey struct {
+	id        string
+	algorithm string
+	key       any
+}
+
+// JWKSFile serves keys from a JWKS document on disk. The file is reloaded
+// whenever its modification time changes, so keys can be rotated by
+// replacing the file without restarting the service.
+type JWKSFile struct {
+	mu        sync.RWMutex
+	path      string
+	modTime   time.Time
+	checkedAt time.Time
+	interval  time.Duration
+	keys      []verificationKey
+}
+
+func NewJWKSFile(path string) (*JWKSFile, error) {
+	f := &JWKSFile{
+		path:     path,
+		interval: 10 * time.Second,
+	}
+
+	if err := f.Reload(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Reload reads the JWKS file again if it changed since the last load
+func (f *JWKSFile) Reload() error {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return err
+	}
+
+	f.mu.RLock()
+	unchanged := info.ModTime().Equal(f.modTime)
+	f.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return err
+	}
+
+	var set jsonWebKeySet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return fmt.Errorf("auth: parsing %s: %w", f.path, err)
+	}
+
+	keys := make([]verificationKey, 0, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := parseJSONWebKey(jwk)
+		if err != nil {
+			return fmt.Errorf("auth: key %q in %s: %w", jwk.KeyID, f.path, err)
+		}
+		keys = append(keys, key)
+	}
+
+	f.mu.Lock()
+	f.keys = keys
+	f.modTime = info.ModTime()
+	f.mu.Unlock()
+
+	return nil
+}
+
+func (f *JWKSFile) Key(keyID, algorithm string) (any, error) {
+	f.refresh()
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	var match *verificationKey
+	for i := range f.keys {
+		key := &f.keys[i]
+		if key.algorithm != "" && key.algorithm != algorithm {
+			continue
+		}
+		if keyID != "" {
+			if key.id == keyID {
+				return key.key, nil
+			}
+			continue
+		}
+		// Tokens without a kid are only accepted when the choice is unambiguous
+		if match != nil {
+			return nil, ErrUnknownKey
+		}
+		match = key
+	}
+
+	if match == nil {
+		return nil, ErrUnknownKey
+	}
+
+	return match.key, nil
+}
+
+func (f *JWKSFile) refresh() {
+	f.mu.Lock()
+	due := time.Since(f.checkedAt) >= f.interval
+	if due {
+		f.checkedAt = time.Now()
+	}
+	f.mu.Unlock()
+
+	if due {
+		// Keep serving the previous keys if the file is briefly unreadable
+		// while it is being replaced
+		_ = f.Reload()
+	}
+}
+
+func parseJSONWebKey(jwk jsonWebKey) (verificationKey, error) {
+	key := verificationKey{id: jwk.KeyID, algorithm: jwk.Algorithm}
+
+	switch jwk.KeyType {
+	case "oct":
+		secret, err := base64.RawURLEncoding.DecodeString(jwk.K)
+		if err != nil {
+			return key, err
+		}
+		key.key = secret
+		if key.algorithm == "" {
+			key.algorithm = AlgorithmHS256
+		}
+
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			return key, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			return key, err
+		}
+		key.key = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+		if key.algorithm == "" {
+			key.algorithm = AlgorithmRS256
+		}
+
+	case "OKP":
+		if jwk.Curve != "Ed25519" {
+			return key, fmt.Errorf("unsupported curve %q", jwk.Curve)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return key, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return key, fmt.Errorf("invalid Ed25519 public key length %d", len(x))
+		}
+		key.key = ed25519.PublicKey(x)
+		if key.algorithm == "" {
+			key.algorithm = AlgorithmEdDSA
+		}
+
+	default:
+		return key, fmt.Errorf("unsupported key type %q", jwk.KeyType)
+	}
+
+	return key, nil
+}
@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+)
+
+// ErrResourceNotFound is returned by ownership lookups when the resource
+// does not exist
+var ErrResourceNotFound = errors.New("auth: resource not found")
+
+// Permissions holds permission codes such as "orders:read" or
+// "restaurants:approve"
+type Permissions []string
+
+func (p Permissions) Include(code string) bool {
+	return slices.Contains(p, code)
+}
+
+// PermissionSource resolves the effective permissions of a principal
+type PermissionSource interface {
+	PermissionsFor(ctx context.Context, principal *Principal) (Permissions, error)
+}
+
+// InMemoryPermissionSource grants permissions per role and per subject. The
+// permissions carried in the token are always included.
+type InMemoryPermissionSource struct {
+	mu       sync.RWMutex
+	roles    map[string]Permissions
+	subjects map[string]Permissions
+}
+
+func NewInMemoryPermissionSource() *InMemoryPermissionSource {
+	return &InMemoryPermissionSource{
+		roles:    make(map[string]Permissions),
+		subjects: make(map[string]Permissions),
+	}
+}
+
+func (s *InMemoryPermissionSource) GrantRole(role string, codes ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.roles[role] = appendUnique(s.roles[role], codes...)
+}
+
+func (s *InMemoryPermissionSource) GrantSubject(subject string, codes ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subjects[subject] = appendUnique(s.subjects[subject], codes...)
+}
+
+func (s *InMemoryPermissionSource) PermissionsFor(ctx context.Context, principal *Principal) (Permissions, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	permissions := appendUnique(nil, principal.Permissions...)
+	for _, role := range principal.Roles {
+		permissions = appendUnique(permissions, s.roles[role]...)
+	}
+	permissions = appendUnique(permissions, s.subjects[principal.Subject]...)
+
+	return permissions, nil
+}
+
+func appendUnique(permissions Permissions, codes ...string) Permissions {
+	for _, code := range codes {
+		if !permissions.Include(code) {
+			permissions = append(permissions, code)
+		}
+	}
+	return permissions
+}
@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leninner/shared/domain/valueobject"
+)
+
+// Principal represents the caller identified by a verified token
+type Principal struct {
+	CustomerID  valueobject.CustomerID
+	Subject     string
+	Activated   bool
+	Roles       []string
+	Permissions Permissions
+	ExpiresAt   time.Time
+}
+
+// AnonymousPrincipal is stored in the request context when no token was sent
+var AnonymousPrincipal = &Principal{}
+
+// IsAnonymous reports whether the principal is the anonymous principal
+func (p *Principal) IsAnonymous() bool {
+	return p == AnonymousPrincipal
+}
+
+// HasRole reports whether the principal was granted the role
+func (p *Principal) HasRole(role string) bool {
+	return slices.Contains(p.Roles, role)
+}
+
+// Owns reports whether the principal is the customer that owns a resource
+func (p *Principal) Owns(owner valueobject.CustomerID) bool {
+	return !p.IsAnonymous() && p.CustomerID.GetValue() == owner.GetValue()
+}
+
+// NewPrincipalFromClaims builds a principal from verified claims. The subject
+// claim must hold the customer UUID.
+func NewPrincipalFromClaims(claims *Claims) (*Principal, error) {
+	id, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	principal := &Principal{
+		CustomerID:  valueobject.NewCustomerIDFromUUID(&id),
+		Subject:     claims.Subject,
+		Activated:   claims.Activated,
+		Roles:       claims.Roles,
+		Permissions: claims.Permissions,
+	}
+
+	if claims.ExpiresAt != 0 {
+		principal.ExpiresAt = time.Unix(claims.ExpiresAt, 0)
+	}
+
+	return principal, nil
+}
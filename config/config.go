@@ -8,8 +8,9 @@ import (
 )
 
 type Config struct {
-	Port int
-	Env  string
+	Port      int
+	AdminPort int
+	Env       string
 	DB   struct {
 		DSN          string
 		MaxOpenConns int
@@ -25,6 +26,11 @@ type Config struct {
 		TrustedOrigins []string
 	}
 	Kafka KafkaConfig
+	Auth     AuthConfig
+	Security    SecurityConfig
+	Maintenance MaintenanceConfig
+	Tracing     TracingConfig
+	Debug       DebugConfig
 }
 
 type KafkaConfig struct {
@@ -41,6 +47,55 @@ type TopicConfig struct {
 	RestaurantApprovalResponse  string
 }
 
+type AuthConfig struct {
+	Issuer     string
+	Audience   string
+	Secret     string
+	JWKSFile   string
+	Algorithms []string
+	Leeway     time.Duration
+}
+
+// SecurityConfig is the policy applied by middleware.SecureHeaders. Empty
+// values leave the corresponding header unset.
+type SecurityConfig struct {
+	HSTSMaxAge            time.Duration
+	HSTSIncludeSubdomains bool
+	HSTSPreload           bool
+	ContentTypeNosniff    bool
+	// TrustForwardedProto sends HSTS on plain HTTP requests whose
+	// X-Forwarded-Proto is https, for services behind a TLS-terminating
+	// proxy. Only enable it when the proxy sets that header.
+	TrustForwardedProto   bool
+	FrameOptions          string
+	ReferrerPolicy        string
+	ContentSecurityPolicy string
+	PermissionsPolicy     string
+}
+
+// MaintenanceConfig sets the initial service mode (normal, read-only or
+// maintenance) used by middleware.ModeSwitch
+type MaintenanceConfig struct {
+	Mode       string
+	RetryAfter time.Duration
+	Allowlist  []string
+}
+
+// TracingConfig selects where spans are exported (stdout, file or none) and
+// the fraction of new traces that are recorded
+type TracingConfig struct {
+	Exporter    string
+	File        string
+	SampleRatio float64
+}
+
+// DebugConfig opts in to tools that must never run in production. They are
+// still refused when Env is production, prod or empty.
+type DebugConfig struct {
+	FaultInjection bool
+	RequestDumps   bool
+}
+
 type Application struct {
 	Config Config
 	Logger *slog.Logger
@@ -50,8 +105,9 @@ type Application struct {
 
 func NewDefaultConfig() Config {
 	return Config{
-		Port: 4000,
-		Env:  "development",
+		Port:      4000,
+		AdminPort: 4001,
+		Env:       "development",
 		DB: struct {
 			DSN          string
 			MaxOpenConns int
@@ -88,5 +144,28 @@ func NewDefaultConfig() Config {
 				RestaurantApprovalResponse:  "restaurant-approval-response",
 			},
 		},
+		Auth: AuthConfig{
+			Algorithms: []string{"HS256", "RS256", "EdDSA"},
+			Leeway:     30 * time.Second,
+		},
+		Security: SecurityConfig{
+			HSTSMaxAge:            180 * 24 * time.Hour,
+			HSTSIncludeSubdomains: true,
+			ContentTypeNosniff:    true,
+			FrameOptions:          "DENY",
+			ReferrerPolicy:        "no-referrer",
+			ContentSecurityPolicy: "default-src 'none'; frame-ancestors 'none'",
+			PermissionsPolicy:     "camera=(), microphone=(), geolocation=(), payment=()",
+		},
+		Maintenance: MaintenanceConfig{
+			Mode:       "normal",
+			RetryAfter: 5 * time.Minute,
+			Allowlist:  []string{"/healthcheck"},
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			File:        "traces.jsonl",
+			SampleRatio: 1,
+		},
 	}
 } 
\ No newline at end of file
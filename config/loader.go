@@ -1,7 +1,9 @@
 package config
 
 import (
+	"errors"
 	"flag"
+	"fmt"
 	"os"
 	"strconv"
 	"strings"
@@ -11,6 +13,7 @@ import (
 type ConfigLoader struct {
 	serviceName string
 	config      Config
+	errs        []error
 }
 
 func NewConfigLoader(serviceName string) *ConfigLoader {
@@ -22,6 +25,7 @@ func NewConfigLoader(serviceName string) *ConfigLoader {
 
 func (l *ConfigLoader) LoadFromFlags() *ConfigLoader {
 	flag.IntVar(&l.config.Port, "port", l.config.Port, "API server port")
+	flag.IntVar(&l.config.AdminPort, "admin-port", l.config.AdminPort, "Admin server port for metrics and operational endpoints (0 disables it)")
 	flag.StringVar(&l.config.Env, "env", l.config.Env, "Environment (development|staging|production)")
 	
 	flag.StringVar(&l.config.DB.DSN, "db-dsn", l.config.DB.DSN, "Database connection string")
@@ -42,6 +46,37 @@ func (l *ConfigLoader) LoadFromFlags() *ConfigLoader {
 	flag.StringVar(&l.config.Kafka.Topics.RestaurantApprovalRequest, "restaurant-approval-request-topic-name", l.config.Kafka.Topics.RestaurantApprovalRequest, "The topic name for the restaurant approval request")
 	flag.StringVar(&l.config.Kafka.Topics.RestaurantApprovalResponse, "restaurant-approval-response-topic-name", l.config.Kafka.Topics.RestaurantApprovalResponse, "The topic name for the restaurant approval response")
 	
+	flag.StringVar(&l.config.Auth.Issuer, "jwt-issuer", l.config.Auth.Issuer, "Expected JWT issuer (iss)")
+	flag.StringVar(&l.config.Auth.Audience, "jwt-audience", l.config.Auth.Audience, "Expected JWT audience (aud)")
+	flag.StringVar(&l.config.Auth.Secret, "jwt-secret", l.config.Auth.Secret, "Shared secret for HS256 tokens")
+	flag.StringVar(&l.config.Auth.JWKSFile, "jwt-jwks-file", l.config.Auth.JWKSFile, "Path to a JWKS file with the token verification keys")
+	flag.Func("jwt-algorithms", "Comma-separated list of accepted JWT algorithms (HS256,RS256,EdDSA)", func(value string) error {
+		l.config.Auth.Algorithms = splitList(value)
+		return nil
+	})
+	flag.DurationVar(&l.config.Auth.Leeway, "jwt-leeway", l.config.Auth.Leeway, "Allowed clock skew when checking token expiry")
+	
+	flag.Func("hsts-max-age", "Strict-Transport-Security max-age in seconds or as a duration (0 disables HSTS)", func(value string) error {
+		d, err := parseSeconds(value)
+		if err != nil {
+			return err
+		}
+		l.config.Security.HSTSMaxAge = d
+		return nil
+	})
+	flag.BoolVar(&l.config.Security.ContentTypeNosniff, "content-type-nosniff", l.config.Security.ContentTypeNosniff, "Send X-Content-Type-Options: nosniff")
+	flag.BoolVar(&l.config.Security.TrustForwardedProto, "trust-forwarded-proto", l.config.Security.TrustForwardedProto, "Send HSTS when X-Forwarded-Proto is https (only behind a proxy that sets it)")
+	flag.StringVar(&l.config.Security.ContentSecurityPolicy, "csp", l.config.Security.ContentSecurityPolicy, "Content-Security-Policy header value")
+	
+	flag.StringVar(&l.config.Maintenance.Mode, "mode", l.config.Maintenance.Mode, "Service mode (normal|read-only|maintenance)")
+	
+	flag.StringVar(&l.config.Tracing.Exporter, "trace-exporter", l.config.Tracing.Exporter, "Span exporter (stdout|file|none)")
+	flag.StringVar(&l.config.Tracing.File, "trace-file", l.config.Tracing.File, "File the file span exporter appends to")
+	flag.Float64Var(&l.config.Tracing.SampleRatio, "trace-sample-ratio", l.config.Tracing.SampleRatio, "Fraction of new traces that are recorded")
+	
+	flag.BoolVar(&l.config.Debug.FaultInjection, "debug-fault-injection", l.config.Debug.FaultInjection, "Allow middleware.NewFaultInjector (never in production)")
+	flag.BoolVar(&l.config.Debug.RequestDumps, "debug-request-dumps", l.config.Debug.RequestDumps, "Allow middleware.DumpRequests (never in production)")
+	
 	flag.Parse()
 	return l
 }
@@ -53,6 +88,12 @@ func (l *ConfigLoader) LoadFromEnv() *ConfigLoader {
 		}
 	}
 	
+	if adminPort := os.Getenv("ADMIN_PORT"); adminPort != "" {
+		if p, err := strconv.Atoi(adminPort); err == nil {
+			l.config.AdminPort = p
+		}
+	}
+	
 	if env := os.Getenv("ENV"); env != "" {
 		l.config.Env = env
 	}
@@ -127,6 +168,92 @@ func (l *ConfigLoader) LoadFromEnv() *ConfigLoader {
 		l.config.CORS.TrustedOrigins = strings.Split(trustedOrigins, ",")
 	}
 	
+	if issuer := os.Getenv("JWT_ISSUER"); issuer != "" {
+		l.config.Auth.Issuer = issuer
+	}
+	
+	if audience := os.Getenv("JWT_AUDIENCE"); audience != "" {
+		l.config.Auth.Audience = audience
+	}
+	
+	if secret := os.Getenv("JWT_SECRET"); secret != "" {
+		l.config.Auth.Secret = secret
+	}
+	
+	if jwksFile := os.Getenv("JWT_JWKS_FILE"); jwksFile != "" {
+		l.config.Auth.JWKSFile = jwksFile
+	}
+	
+	if algorithms := os.Getenv("JWT_ALGORITHMS"); algorithms != "" {
+		l.config.Auth.Algorithms = splitList(algorithms)
+	}
+	
+	if leeway := os.Getenv("JWT_LEEWAY"); leeway != "" {
+		if d, err := time.ParseDuration(leeway); err == nil {
+			l.config.Auth.Leeway = d
+		}
+	}
+	
+	if hstsMaxAge := os.Getenv("SECURITY_HSTS_MAX_AGE"); hstsMaxAge != "" {
+		if d, err := parseSeconds(hstsMaxAge); err == nil {
+			l.config.Security.HSTSMaxAge = d
+		} else {
+			l.invalid("SECURITY_HSTS_MAX_AGE", err)
+		}
+	}
+	
+	l.envBool("SECURITY_HSTS_INCLUDE_SUBDOMAINS", &l.config.Security.HSTSIncludeSubdomains)
+	l.envBool("SECURITY_HSTS_PRELOAD", &l.config.Security.HSTSPreload)
+	l.envBool("SECURITY_CONTENT_TYPE_NOSNIFF", &l.config.Security.ContentTypeNosniff)
+	l.envBool("SECURITY_TRUST_FORWARDED_PROTO", &l.config.Security.TrustForwardedProto)
+	
+	if frameOptions := os.Getenv("SECURITY_FRAME_OPTIONS"); frameOptions != "" {
+		l.config.Security.FrameOptions = frameOptions
+	}
+	
+	if referrerPolicy := os.Getenv("SECURITY_REFERRER_POLICY"); referrerPolicy != "" {
+		l.config.Security.ReferrerPolicy = referrerPolicy
+	}
+	
+	if csp := os.Getenv("SECURITY_CSP"); csp != "" {
+		l.config.Security.ContentSecurityPolicy = csp
+	}
+	
+	if permissionsPolicy := os.Getenv("SECURITY_PERMISSIONS_POLICY"); permissionsPolicy != "" {
+		l.config.Security.PermissionsPolicy = permissionsPolicy
+	}
+	
+	if mode := os.Getenv("SERVICE_MODE"); mode != "" {
+		l.config.Maintenance.Mode = mode
+	}
+	
+	if retryAfter := os.Getenv("MAINTENANCE_RETRY_AFTER"); retryAfter != "" {
+		if d, err := time.ParseDuration(retryAfter); err == nil {
+			l.config.Maintenance.RetryAfter = d
+		}
+	}
+	
+	if allowlist := os.Getenv("MAINTENANCE_ALLOWLIST"); allowlist != "" {
+		l.config.Maintenance.Allowlist = strings.Split(allowlist, ",")
+	}
+	
+	if exporter := os.Getenv("TRACE_EXPORTER"); exporter != "" {
+		l.config.Tracing.Exporter = exporter
+	}
+	
+	if file := os.Getenv("TRACE_FILE"); file != "" {
+		l.config.Tracing.File = file
+	}
+	
+	if ratio := os.Getenv("TRACE_SAMPLE_RATIO"); ratio != "" {
+		if r, err := strconv.ParseFloat(ratio, 64); err == nil {
+			l.config.Tracing.SampleRatio = r
+		}
+	}
+	
+	l.envBool("DEBUG_FAULT_INJECTION", &l.config.Debug.FaultInjection)
+	l.envBool("DEBUG_REQUEST_DUMPS", &l.config.Debug.RequestDumps)
+	
 	return l
 }
 
@@ -134,7 +261,71 @@ func (l *ConfigLoader) Build() Config {
 	return l.config
 }
 
+// Err returns the invalid environment values found so far. Values that fail
+// to parse keep their defaults.
+func (l *ConfigLoader) Err() error {
+	return errors.Join(l.errs...)
+}
+
+func (l *ConfigLoader) invalid(name string, err error) {
+	l.errs = append(l.errs, fmt.Errorf("config: invalid %s: %w", name, err))
+}
+
+func (l *ConfigLoader) envBool(name string, target *bool) {
+	value := os.Getenv(name)
+	if value == "" {
+		return
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		l.invalid(name, err)
+		return
+	}
+	*target = b
+}
+
+// parseSeconds accepts a number of seconds, as in max-age, or a duration
+// such as "4320h"
+// splitList splits a comma-separated value, trimming spaces and dropping
+// empty entries, so "HS256, RS256" gives HS256 and RS256
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func parseSeconds(value string) (time.Duration, error) {
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0, fmt.Errorf("%q must not be negative", value)
+		}
+		return time.Duration(seconds) * time.Second, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%q is neither seconds nor a duration", value)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("%q must not be negative", value)
+	}
+	return d, nil
+}
+
+// LoadConfig loads the configuration from flags and the environment,
+// ignoring invalid values. Use Load to have them reported.
 func LoadConfig(serviceName string) Config {
 	loader := NewConfigLoader(serviceName)
 	return loader.LoadFromFlags().LoadFromEnv().Build()
+}
+
+// Load is LoadConfig returning an error for invalid environment values
+func Load(serviceName string) (Config, error) {
+	loader := NewConfigLoader(serviceName).LoadFromFlags().LoadFromEnv()
+	return loader.Build(), loader.Err()
 } 
\ No newline at end of file
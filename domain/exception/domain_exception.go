@@ -2,6 +2,89 @@ package exception
 
 import "errors"
 
+// Kind classifies a domain error. Kinds are errors themselves so callers
+// can test for them with errors.Is(err, exception.NotFound).
+type Kind string
+
+const (
+	NotFound   Kind = "not_found"
+	Conflict   Kind = "conflict"
+	Validation Kind = "validation"
+	Forbidden  Kind = "forbidden"
+	Invariant  Kind = "invariant"
+)
+
+func (k Kind) Error() string {
+	return string(k)
+}
+
+// DomainError is an error raised by the domain model. Message is meant to be
+// shown to clients; Err, if set, is the underlying cause.
+type DomainError struct {
+	Kind    Kind
+	Message string
+	// Fields holds per-field messages for Validation errors
+	Fields map[string]string
+	Err    error
+}
+
+func (e *DomainError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *DomainError) Unwrap() error {
+	return e.Err
+}
+
+func (e *DomainError) Is(target error) bool {
+	kind, ok := target.(Kind)
+	return ok && kind == e.Kind
+}
+
+func New(kind Kind, message string) error {
+	return &DomainError{Kind: kind, Message: message}
+}
+
+// Wrap attaches a kind and a client-facing message to err
+func Wrap(kind Kind, err error, message string) error {
+	return &DomainError{Kind: kind, Message: message, Err: err}
+}
+
+func NewNotFound(message string) error {
+	return New(NotFound, message)
+}
+
+func NewConflict(message string) error {
+	return New(Conflict, message)
+}
+
+func NewValidation(message string, fields map[string]string) error {
+	return &DomainError{Kind: Validation, Message: message, Fields: fields}
+}
+
+func NewForbidden(message string) error {
+	return New(Forbidden, message)
+}
+
+func NewInvariant(message string) error {
+	return New(Invariant, message)
+}
+
+// NewDomainException reports a broken business rule. It's kept for existing
+// callers; new code should use the constructor for the specific kind.
 func NewDomainException(message string) error {
-	return errors.New(message)
-}
\ No newline at end of file
+	return NewInvariant(message)
+}
+
+// KindOf returns the kind of the first DomainError in err's chain, or an
+// empty Kind if there is none
+func KindOf(err error) Kind {
+	var domainErr *DomainError
+	if errors.As(err, &domainErr) {
+		return domainErr.Kind
+	}
+	return ""
+}
@@ -1,26 +1,62 @@
 package exception
 
 import (
-	"fmt"
-	"log"
+	"errors"
 	"net/http"
 
+	"github.com/leninner/shared/i18n"
+	"github.com/leninner/shared/reporting"
 	"github.com/leninner/shared/utils"
+	"github.com/leninner/shared/utils/validator"
 )
 
-func LogError(r *http.Request, err error) {
-	var (
-		method = r.Method
-		uri    = r.URL.RequestURI()
-	)
+// Error codes are stable identifiers clients can switch on. They're sent in
+// the code member of problem+json responses.
+const (
+	CodeServerError                = "server_error"
+	CodeNotFound                   = "not_found"
+	CodeMethodNotAllowed           = "method_not_allowed"
+	CodeBadRequest                 = "bad_request"
+	CodeFailedValidation           = "failed_validation"
+	CodeEditConflict               = "edit_conflict"
+	CodePreconditionFailed         = "precondition_failed"
+	CodePreconditionRequired       = "precondition_required"
+	CodeIdempotencyKeyInUse        = "idempotency_key_in_use"
+	CodeIdempotencyKeyMismatch     = "idempotency_key_mismatch"
+	CodeRateLimitExceeded          = "rate_limit_exceeded"
+	CodeServiceUnavailable         = "service_unavailable"
+	CodeReadOnlyMode               = "read_only_mode"
+	CodeMaintenanceMode            = "maintenance_mode"
+	CodeGatewayTimeout             = "gateway_timeout"
+	CodeRequestTooLarge            = "request_too_large"
+	CodeInvalidCredentials         = "invalid_credentials"
+	CodeInvalidAuthenticationToken = "invalid_authentication_token"
+	CodeAuthenticationRequired     = "authentication_required"
+	CodeInactiveAccount            = "inactive_account"
+	CodeNotPermitted               = "not_permitted"
+	CodeConflict                   = "conflict"
+	CodeForbidden                  = "forbidden"
+	CodeRuleViolation              = "rule_violation"
+)
 
-	log.Println(err.Error(), "method", method, "uri", uri)
+func localize(r *http.Request, key string, params i18n.Params) string {
+	return i18n.Translate(i18n.LocaleFromRequest(r), key, params)
 }
 
 func ErrorResponse(w http.ResponseWriter, r *http.Request, status int, message any) {
-	env := utils.Envelope{"error": message}
+	ErrorResponseWithCode(w, r, status, codeForStatus(status), message)
+}
+
+// ErrorResponseWithCode writes the error as problem+json when the service or
+// the client asks for it, and as the {"error": message} envelope otherwise
+func ErrorResponseWithCode(w http.ResponseWriter, r *http.Request, status int, code string, message any) {
+	var err error
+	if wantsProblem(w, r) {
+		err = writeProblem(w, newProblem(r, status, code, message))
+	} else {
+		err = utils.WriteJSON(w, status, utils.Envelope{"error": message}, nil)
+	}
 
-	err := utils.WriteJSON(w, status, env, nil)
 	if err != nil {
 		LogError(r, err)
 		w.WriteHeader(500)
@@ -30,61 +66,121 @@ func ErrorResponse(w http.ResponseWriter, r *http.Request, status int, message a
 func ServerErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
 	LogError(r, err)
 
-	message := "the server encountered a problem and could not process your request"
-	ErrorResponse(w, r, http.StatusInternalServerError, message)
+	// Recovered panics were already reported when they were recovered
+	var panicErr *utils.PanicError
+	if !errors.As(err, &panicErr) {
+		reporting.CaptureRequest(r, err)
+	}
+
+	message := localize(r, "errors.server_error", nil)
+	ErrorResponseWithCode(w, r, http.StatusInternalServerError, CodeServerError, message)
 }
 
 func NotFoundResponse(w http.ResponseWriter, r *http.Request) {
-	message := "the requested resource could not be found"
-	ErrorResponse(w, r, http.StatusNotFound, message)
+	message := localize(r, "errors.not_found", nil)
+	ErrorResponseWithCode(w, r, http.StatusNotFound, CodeNotFound, message)
 }
 
 func MethodNotAllowedResponse(w http.ResponseWriter, r *http.Request) {
-	message := fmt.Sprintf("the %s method is not supported for this resource", r.Method)
-	ErrorResponse(w, r, http.StatusMethodNotAllowed, message)
+	message := localize(r, "errors.method_not_allowed", i18n.Params{"method": r.Method})
+	ErrorResponseWithCode(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, message)
 }
 
+// BadRequestResponse sends the error's text, translated when err is or wraps
+// an *i18n.Error
 func BadRequestResponse(w http.ResponseWriter, r *http.Request, err error) {
-	ErrorResponse(w, r, http.StatusBadRequest, err.Error())
+	message := err.Error()
+
+	var localized *i18n.Error
+	if errors.As(err, &localized) {
+		message = localized.Localize(i18n.LocaleFromRequest(r))
+	}
+
+	ErrorResponseWithCode(w, r, http.StatusBadRequest, CodeBadRequest, message)
 }
 
 func FailedValidationResponse(w http.ResponseWriter, r *http.Request, errors map[string]string) {
-	ErrorResponse(w, r, http.StatusUnprocessableEntity, errors)
+	ValidationErrorResponse(w, r, &validator.ValidationError{Errors: errors})
 }
 
 func EditConflictResponse(w http.ResponseWriter, r *http.Request) {
-	message := "unable to update the record due to an edit conflict, please try again"
-	ErrorResponse(w, r, http.StatusConflict, message)
+	message := localize(r, "errors.edit_conflict", nil)
+	ErrorResponseWithCode(w, r, http.StatusConflict, CodeEditConflict, message)
+}
+
+func PreconditionFailedResponse(w http.ResponseWriter, r *http.Request) {
+	message := localize(r, "errors.precondition_failed", nil)
+	ErrorResponseWithCode(w, r, http.StatusPreconditionFailed, CodePreconditionFailed, message)
+}
+
+func PreconditionRequiredResponse(w http.ResponseWriter, r *http.Request) {
+	message := localize(r, "errors.precondition_required", nil)
+	ErrorResponseWithCode(w, r, http.StatusPreconditionRequired, CodePreconditionRequired, message)
+}
+
+func IdempotencyKeyInUseResponse(w http.ResponseWriter, r *http.Request) {
+	message := localize(r, "errors.idempotency_key_in_use", nil)
+	ErrorResponseWithCode(w, r, http.StatusConflict, CodeIdempotencyKeyInUse, message)
+}
+
+func IdempotencyKeyMismatchResponse(w http.ResponseWriter, r *http.Request) {
+	message := localize(r, "errors.idempotency_key_mismatch", nil)
+	ErrorResponseWithCode(w, r, http.StatusUnprocessableEntity, CodeIdempotencyKeyMismatch, message)
 }
 
 func RateLimitExceededResponse(w http.ResponseWriter, r *http.Request) {
-	message := "rate limit exceeded"
-	ErrorResponse(w, r, http.StatusTooManyRequests, message)
+	message := localize(r, "errors.rate_limit_exceeded", nil)
+	ErrorResponseWithCode(w, r, http.StatusTooManyRequests, CodeRateLimitExceeded, message)
+}
+
+func ServiceUnavailableResponse(w http.ResponseWriter, r *http.Request) {
+	message := localize(r, "errors.service_unavailable", nil)
+	ErrorResponseWithCode(w, r, http.StatusServiceUnavailable, CodeServiceUnavailable, message)
+}
+
+func ReadOnlyModeResponse(w http.ResponseWriter, r *http.Request) {
+	message := localize(r, "errors.read_only_mode", nil)
+	ErrorResponseWithCode(w, r, http.StatusServiceUnavailable, CodeReadOnlyMode, message)
+}
+
+func MaintenanceModeResponse(w http.ResponseWriter, r *http.Request) {
+	message := localize(r, "errors.maintenance_mode", nil)
+	ErrorResponseWithCode(w, r, http.StatusServiceUnavailable, CodeMaintenanceMode, message)
+}
+
+func GatewayTimeoutResponse(w http.ResponseWriter, r *http.Request) {
+	message := localize(r, "errors.gateway_timeout", nil)
+	ErrorResponseWithCode(w, r, http.StatusGatewayTimeout, CodeGatewayTimeout, message)
+}
+
+func RequestTooLargeResponse(w http.ResponseWriter, r *http.Request, limit int64) {
+	message := localize(r, "errors.request_too_large", i18n.Params{"limit": limit})
+	ErrorResponseWithCode(w, r, http.StatusRequestEntityTooLarge, CodeRequestTooLarge, message)
 }
 
 func InvalidCredentialsResponse(w http.ResponseWriter, r *http.Request) {
-	message := "invalid authentication credentials"
-	ErrorResponse(w, r, http.StatusUnauthorized, message)
+	message := localize(r, "errors.invalid_credentials", nil)
+	ErrorResponseWithCode(w, r, http.StatusUnauthorized, CodeInvalidCredentials, message)
 }
 
 func InvalidAuthenticationTokenResponse(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("WWW-Authenticate", "Bearer")
 
-	message := "invalid or missing authentication token"
-	ErrorResponse(w, r, http.StatusUnauthorized, message)
+	message := localize(r, "errors.invalid_authentication_token", nil)
+	ErrorResponseWithCode(w, r, http.StatusUnauthorized, CodeInvalidAuthenticationToken, message)
 }
 
 func AuthenticationRequiredResponse(w http.ResponseWriter, r *http.Request) {
-	message := "you must be authenticated to access this resource"
-	ErrorResponse(w, r, http.StatusUnauthorized, message)
+	message := localize(r, "errors.authentication_required", nil)
+	ErrorResponseWithCode(w, r, http.StatusUnauthorized, CodeAuthenticationRequired, message)
 }
 
 func InactiveAccountResponse(w http.ResponseWriter, r *http.Request) {
-	message := "your user account must be activated to access this resource"
-	ErrorResponse(w, r, http.StatusForbidden, message)
+	message := localize(r, "errors.inactive_account", nil)
+	ErrorResponseWithCode(w, r, http.StatusForbidden, CodeInactiveAccount, message)
 }
 
 func NotPermittedResponse(w http.ResponseWriter, r *http.Request) {
-	message := "your user account doesn't have the necessary permissions to access this resource"
-	ErrorResponse(w, r, http.StatusForbidden, message)
-} 
\ No newline at end of file
+	message := localize(r, "errors.not_permitted", nil)
+	ErrorResponseWithCode(w, r, http.StatusForbidden, CodeNotPermitted, message)
+}
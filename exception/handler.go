@@ -0,0 +1,53 @@
+package exception
+
+import (
+	"net/http"
+)
+
+// HandlerFunc is a handler that returns its error instead of writing the
+// error response itself. It implements http.Handler, answering returned
+// errors through HandleError:
+//
+//	rt.Handle(http.MethodGet, "/v1/orders/:id", exception.HandlerFunc(app.showOrderHandler))
+//
+//	func (app *application) showOrderHandler(w http.ResponseWriter, r *http.Request) error {
+//		id, err := utils.ReadUUIDParamByName(r, "id")
+//		if err != nil {
+//			return exception.BadRequest(err)
+//		}
+//
+//		order, err := app.orders.Get(r.Context(), id)
+//		if err != nil {
+//			return err
+//		}
+//
+//		return utils.WriteJSON(w, http.StatusOK, utils.Envelope{"order": order}, nil)
+//	}
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+func (fn HandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	err := fn(w, r)
+	if err != nil {
+		HandleError(w, r, err)
+	}
+}
+
+// BadRequestError marks an error caused by a malformed request, such as the
+// errors returned by utils.ReadJSON
+type BadRequestError struct {
+	Err error
+}
+
+func (e *BadRequestError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *BadRequestError) Unwrap() error {
+	return e.Err
+}
+
+// BadRequest wraps err so HandleError answers it with a 400 showing its
+// message
+func BadRequest(err error) error {
+	return &BadRequestError{Err: err}
+}
@@ -0,0 +1,88 @@
+package exception
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/leninner/shared/auth"
+	"github.com/leninner/shared/logger"
+	"github.com/leninner/shared/utils"
+)
+
+var errLogger atomic.Pointer[slog.Logger]
+
+// SetLogger sets the logger errors are written to when the request context
+// has none, usually the service's config.Application.Logger; server.Serve
+// sets it. Until it's called errors go to slog.Default().
+func SetLogger(l *slog.Logger) {
+	errLogger.Store(l)
+}
+
+// requestLogger returns the request's logger, stored by
+// middleware.RequestLogger, and whether it already carries the request
+// fields; otherwise the logger set with SetLogger
+func requestLogger(r *http.Request) (*slog.Logger, bool) {
+	if l, ok := logger.Lookup(r.Context()); ok {
+		return l, true
+	}
+	if l := errLogger.Load(); l != nil {
+		return l, false
+	}
+	return slog.Default(), false
+}
+
+// LogError logs err with the request it happened in: method, URI, route,
+// request ID, client IP, principal, the chain of wrapped errors and, for
+// recovered panics, the stack trace
+func LogError(r *http.Request, err error) {
+	l, scoped := requestLogger(r)
+	l.LogAttrs(r.Context(), slog.LevelError, "request failed", requestAttrs(r, err, scoped)...)
+}
+
+// logMapped logs, at warn level, an error HandleError answered with a
+// built-in mapping rather than a 500, so a missing row or a slow query still
+// leaves a trace
+func logMapped(r *http.Request, err error, status int) {
+	l, scoped := requestLogger(r)
+	attrs := append(requestAttrs(r, err, scoped), slog.Int("status", status))
+	l.LogAttrs(r.Context(), slog.LevelWarn, "request error mapped", attrs...)
+}
+
+// requestAttrs leaves out the method, route, request ID and principal when
+// the logger is the request's own, which already has them
+func requestAttrs(r *http.Request, err error, scoped bool) []slog.Attr {
+	attrs := []slog.Attr{
+		slog.String("error", err.Error()),
+		slog.String("uri", r.URL.RequestURI()),
+		slog.String("client_ip", utils.ClientIP(r)),
+	}
+
+	if !scoped {
+		attrs = append(attrs, slog.String("method", r.Method))
+
+		if route := utils.ContextGetRoute(r); route != "" {
+			attrs = append(attrs, slog.String("route", route))
+		}
+
+		if id := utils.ContextGetRequestID(r); id != "" {
+			attrs = append(attrs, slog.String("request_id", id))
+		}
+
+		if principal, ok := auth.PrincipalFromContext(r.Context()); ok && !principal.IsAnonymous() {
+			attrs = append(attrs, slog.String("principal", principal.Subject))
+		}
+	}
+
+	if chain := utils.ErrorChain(err); len(chain) > 1 {
+		attrs = append(attrs, slog.Any("causes", chain[1:]))
+	}
+
+	var panicErr *utils.PanicError
+	if errors.As(err, &panicErr) {
+		attrs = append(attrs, slog.String("stack", string(panicErr.Stack)))
+	}
+
+	return attrs
+}
@@ -0,0 +1,122 @@
+package exception
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"slices"
+	"sync"
+
+	domainexception "github.com/leninner/shared/domain/exception"
+	"github.com/leninner/shared/utils/validator"
+)
+
+// Mapping is the response an error is turned into. An empty Message sends
+// the error's own text, so only map errors whose text is safe to expose.
+type Mapping struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+type registeredMapping struct {
+	target  error
+	mapping Mapping
+}
+
+var (
+	mappingsMu sync.RWMutex
+	mappings   []registeredMapping
+)
+
+// RegisterError maps errors matching target with errors.Is to a response,
+// for service-specific errors such as data.ErrEditConflict. Registered
+// mappings are checked before the built-in ones, in registration order.
+func RegisterError(target error, mapping Mapping) {
+	if mapping.Code == "" {
+		mapping.Code = codeForStatus(mapping.Status)
+	}
+
+	mappingsMu.Lock()
+	defer mappingsMu.Unlock()
+
+	// Clipped so HandleError can keep ranging over the previous slice
+	mappings = append(slices.Clip(mappings), registeredMapping{target: target, mapping: mapping})
+}
+
+// HandleError writes the response for err: registered mappings first, then
+// domain error kinds, validation and bad request errors, then well-known
+// errors. Any other error is logged and answered with a 500.
+//
+// The well-known errors are logged at warn level: sql.ErrNoRows is answered
+// with a 404, on the assumption that the missing row is the requested
+// resource, and context.DeadlineExceeded with a 504. Register a mapping for
+// sql.ErrNoRows, or wrap it in a domain error, where a missing row means
+// something else, e.g. a broken reference that should be a 500.
+func HandleError(w http.ResponseWriter, r *http.Request, err error) {
+	mappingsMu.RLock()
+	registered := mappings
+	mappingsMu.RUnlock()
+
+	for _, m := range registered {
+		if errors.Is(err, m.target) {
+			message := m.mapping.Message
+			if message == "" {
+				message = err.Error()
+			}
+			ErrorResponseWithCode(w, r, m.mapping.Status, m.mapping.Code, message)
+			return
+		}
+	}
+
+	var domainErr *domainexception.DomainError
+	if errors.As(err, &domainErr) {
+		domainErrorResponse(w, r, domainErr)
+		return
+	}
+
+	var validationErr *validator.ValidationError
+	if errors.As(err, &validationErr) {
+		ValidationErrorResponse(w, r, validationErr)
+		return
+	}
+
+	var badRequestErr *BadRequestError
+	if errors.As(err, &badRequestErr) {
+		BadRequestResponse(w, r, badRequestErr.Err)
+		return
+	}
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		logMapped(r, err, http.StatusNotFound)
+		NotFoundResponse(w, r)
+	case errors.Is(err, context.DeadlineExceeded):
+		logMapped(r, err, http.StatusGatewayTimeout)
+		GatewayTimeoutResponse(w, r)
+	default:
+		ServerErrorResponse(w, r, err)
+	}
+}
+
+func domainErrorResponse(w http.ResponseWriter, r *http.Request, err *domainexception.DomainError) {
+	switch err.Kind {
+	case domainexception.NotFound:
+		ErrorResponseWithCode(w, r, http.StatusNotFound, CodeNotFound, err.Message)
+	case domainexception.Conflict:
+		ErrorResponseWithCode(w, r, http.StatusConflict, CodeConflict, err.Message)
+	case domainexception.Validation:
+		if len(err.Fields) > 0 {
+			FailedValidationResponse(w, r, err.Fields)
+			return
+		}
+		ErrorResponseWithCode(w, r, http.StatusUnprocessableEntity, CodeFailedValidation, err.Message)
+	case domainexception.Forbidden:
+		ErrorResponseWithCode(w, r, http.StatusForbidden, CodeForbidden, err.Message)
+	case domainexception.Invariant:
+		ErrorResponseWithCode(w, r, http.StatusUnprocessableEntity, CodeRuleViolation, err.Message)
+	default:
+		ServerErrorResponse(w, r, err)
+	}
+}
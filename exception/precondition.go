@@ -0,0 +1,33 @@
+package exception
+
+import (
+	"net/http"
+
+	"github.com/leninner/shared/utils"
+)
+
+// CheckIfMatch compares the If-Match header with the entity's current tag,
+// e.g. utils.VersionETag(order.Version), and answers with 412 when the client
+// updated a stale copy. It reports whether the handler may go on:
+//
+// With required set, a request without If-Match is answered with 428:
+//
+//	if !exception.CheckIfMatch(w, r, utils.VersionETag(order.Version), true) {
+//		return
+//	}
+func CheckIfMatch(w http.ResponseWriter, r *http.Request, etag string, required bool) bool {
+	if !utils.HasIfMatch(r) {
+		if required {
+			PreconditionRequiredResponse(w, r)
+			return false
+		}
+		return true
+	}
+
+	if !utils.IfMatch(r, etag) {
+		PreconditionFailedResponse(w, r)
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,173 @@
+package exception
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/leninner/shared/utils"
+)
+
+// Format selects how error responses are written
+type Format int
+
+const (
+	// FormatEnvelope writes {"error": message}, the format existing clients
+	// understand
+	FormatEnvelope Format = iota
+	// FormatProblem writes RFC 7807 application/problem+json documents
+	FormatProblem
+)
+
+const ProblemContentType = "application/problem+json"
+
+var (
+	format          atomic.Int32
+	problemTypeBase atomic.Pointer[string]
+)
+
+// SetFormat sets the default error format for the service. Clients can still
+// ask for problem+json through the Accept header when the default is the
+// envelope.
+func SetFormat(f Format) {
+	format.Store(int32(f))
+}
+
+// SetProblemTypeBase sets the URI that error codes are appended to in the
+// problem type member, e.g. "https://errors.taneats.com/" gives
+// "https://errors.taneats.com/not_found". Left empty, the type is
+// "about:blank".
+func SetProblemTypeBase(base string) {
+	problemTypeBase.Store(&base)
+}
+
+// Problem is an RFC 7807 problem detail. Code is a stable, machine-readable
+// identifier for the error; Extensions are written as additional members.
+type Problem struct {
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	Code       string
+	Extensions map[string]any
+}
+
+func (p Problem) MarshalJSON() ([]byte, error) {
+	members := make(map[string]any, len(p.Extensions)+6)
+	for key, value := range p.Extensions {
+		members[key] = value
+	}
+
+	members["type"] = p.Type
+	members["title"] = p.Title
+	members["status"] = p.Status
+	members["code"] = p.Code
+	if p.Detail != "" {
+		members["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		members["instance"] = p.Instance
+	}
+
+	return json.Marshal(members)
+}
+
+func newProblem(r *http.Request, status int, code string, message any) Problem {
+	p := Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Instance: r.URL.Path,
+		Code:     code,
+	}
+
+	if base := problemTypeBase.Load(); base != nil && *base != "" {
+		p.Type = *base + code
+	}
+
+	switch m := message.(type) {
+	case string:
+		p.Detail = m
+	case nil:
+	default:
+		p.Detail = localize(r, "errors.failed_validation", nil)
+		p.Extensions = map[string]any{"errors": m}
+	}
+
+	if id := utils.ContextGetRequestID(r); id != "" {
+		if p.Extensions == nil {
+			p.Extensions = make(map[string]any)
+		}
+		p.Extensions["request_id"] = id
+	}
+
+	return p
+}
+
+func writeProblem(w http.ResponseWriter, p Problem) error {
+	js, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(p.Status)
+	w.Write(append(js, '\n'))
+
+	return nil
+}
+
+// wantsProblem reports whether the response should be problem+json, either
+// because it's the service default or because the client accepts it with a
+// q-value above 0. When the client decides, the response varies by Accept.
+func wantsProblem(w http.ResponseWriter, r *http.Request) bool {
+	if Format(format.Load()) == FormatProblem {
+		return true
+	}
+
+	addVary(w.Header(), "Accept")
+
+	for _, mediaRange := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+		if err != nil || mediaType != ProblemContentType {
+			continue
+		}
+
+		if q, ok := params["q"]; ok {
+			value, err := strconv.ParseFloat(q, 64)
+			if err != nil || value <= 0 {
+				return false
+			}
+		}
+		return true
+	}
+
+	return false
+}
+
+func addVary(h http.Header, name string) {
+	for _, value := range h.Values("Vary") {
+		for _, field := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(field), name) {
+				return
+			}
+		}
+	}
+
+	h.Add("Vary", name)
+}
+
+// codeForStatus derives a code from the status text, e.g. too_many_requests,
+// for responses written without an explicit code
+func codeForStatus(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return "error"
+	}
+
+	return strings.ReplaceAll(strings.ToLower(text), " ", "_")
+}
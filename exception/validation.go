@@ -0,0 +1,164 @@
+package exception
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/leninner/shared/utils/validator"
+)
+
+// ValidationLayout selects how the dotted keys built by validator envelopes,
+// such as "items.0.price", are rendered in validation error responses
+type ValidationLayout int
+
+const (
+	// ValidationFlat keeps the dotted keys: {"items.0.price": ...}
+	ValidationFlat ValidationLayout = iota
+	// ValidationNested renders objects and arrays:
+	// {"items": [{"price": ...}]}. A key that has its own errors and
+	// nested ones keeps its own under "_errors".
+	ValidationNested
+	// ValidationPointer uses JSON Pointer keys: {"/items/0/price": ...}
+	ValidationPointer
+)
+
+type ValidationOptions struct {
+	Layout ValidationLayout
+	// Detailed sends every failed rule of a field as a list of
+	// {"code", "message"} objects instead of its first message
+	Detailed bool
+}
+
+var validationOptions atomic.Pointer[ValidationOptions]
+
+// SetValidationOptions sets how validation errors are rendered for the
+// service
+func SetValidationOptions(opts ValidationOptions) {
+	validationOptions.Store(&opts)
+}
+
+// ValidationErrorResponse answers a *validator.ValidationError, which keeps
+// every failed rule, so the detailed layout has all of them available
+func ValidationErrorResponse(w http.ResponseWriter, r *http.Request, err *validator.ValidationError) {
+	var opts ValidationOptions
+	if o := validationOptions.Load(); o != nil {
+		opts = *o
+	}
+
+	fields := make(map[string]any, len(err.Errors))
+	for key, message := range err.Errors {
+		if opts.Detailed && len(err.Details[key]) > 0 {
+			fields[key] = err.Details[key]
+		} else {
+			fields[key] = fieldValue(opts, message)
+		}
+	}
+
+	ErrorResponseWithCode(w, r, http.StatusUnprocessableEntity, CodeFailedValidation, renderFields(opts, fields))
+}
+
+func fieldValue(opts ValidationOptions, message string) any {
+	if opts.Detailed {
+		return []validator.FieldError{{Code: "invalid", Message: message}}
+	}
+	return message
+}
+
+func renderFields(opts ValidationOptions, fields map[string]any) any {
+	switch opts.Layout {
+	case ValidationNested:
+		return nestFields(fields)
+	case ValidationPointer:
+		pointers := make(map[string]any, len(fields))
+		for key, value := range fields {
+			pointers[jsonPointer(key)] = value
+		}
+		return pointers
+	default:
+		return fields
+	}
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+func jsonPointer(key string) string {
+	var b strings.Builder
+	for _, segment := range strings.Split(key, ".") {
+		b.WriteByte('/')
+		b.WriteString(pointerEscaper.Replace(segment))
+	}
+	return b.String()
+}
+
+type fieldNode struct {
+	value    any
+	children map[string]*fieldNode
+}
+
+func nestFields(fields map[string]any) any {
+	root := &fieldNode{}
+
+	for key, value := range fields {
+		node := root
+		for _, segment := range strings.Split(key, ".") {
+			if node.children == nil {
+				node.children = make(map[string]*fieldNode)
+			}
+			child, ok := node.children[segment]
+			if !ok {
+				child = &fieldNode{}
+				node.children[segment] = child
+			}
+			node = child
+		}
+		node.value = value
+	}
+
+	return root.render()
+}
+
+// render turns children with numeric keys into arrays, with null for the
+// indexes that have no errors
+func (n *fieldNode) render() any {
+	if len(n.children) == 0 {
+		return n.value
+	}
+
+	if n.value == nil {
+		if length, ok := arrayLength(n.children); ok {
+			items := make([]any, length)
+			for key, child := range n.children {
+				index, _ := strconv.Atoi(key)
+				items[index] = child.render()
+			}
+			return items
+		}
+	}
+
+	object := make(map[string]any, len(n.children)+1)
+	for key, child := range n.children {
+		object[key] = child.render()
+	}
+	if n.value != nil {
+		object["_errors"] = n.value
+	}
+	return object
+}
+
+// maxArrayLength stops a key like "items.1000000" from allocating a huge
+// array; such keys are rendered as object members instead
+const maxArrayLength = 1000
+
+func arrayLength(children map[string]*fieldNode) (int, bool) {
+	length := 0
+	for key := range children {
+		index, err := strconv.Atoi(key)
+		if err != nil || index < 0 || index >= maxArrayLength || strconv.Itoa(index) != key {
+			return 0, false
+		}
+		length = max(length, index+1)
+	}
+	return length, true
+}
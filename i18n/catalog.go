@@ -0,0 +1,153 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// DefaultLocale is used when the client accepts none of the catalog's
+// locales, and for keys missing from a locale's messages
+const DefaultLocale = "en"
+
+//go:embed locales/*.json
+var embedded embed.FS
+
+// Default holds the messages shipped with the module, English and Spanish
+var Default = mustLoadEmbedded()
+
+// Params are the values substituted for {name} placeholders in a message
+type Params map[string]any
+
+// Message is a message key with its parameters, translated once the locale
+// is known
+type Message struct {
+	Key    string
+	Params Params
+}
+
+// Catalog maps locales to their messages, keyed by message key such as
+// "errors.not_found" or "validation.required"
+type Catalog struct {
+	mu       sync.RWMutex
+	messages map[string]map[string]string
+}
+
+func NewCatalog() *Catalog {
+	return &Catalog{messages: make(map[string]map[string]string)}
+}
+
+// Load adds every <locale>.json file in dir of fsys, so services can embed
+// their own messages or override the shipped ones
+func (c *Catalog) Load(fsys fs.FS, dir string) error {
+	files, err := fs.Glob(fsys, path.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return err
+		}
+
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return fmt.Errorf("i18n: %s: %w", file, err)
+		}
+
+		c.Add(strings.TrimSuffix(path.Base(file), ".json"), messages)
+	}
+
+	return nil
+}
+
+// Add merges messages into the locale, replacing existing keys
+func (c *Catalog) Add(locale string, messages map[string]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	locale = strings.ToLower(locale)
+	if c.messages[locale] == nil {
+		c.messages[locale] = make(map[string]string, len(messages))
+	}
+
+	for key, message := range messages {
+		c.messages[locale][key] = message
+	}
+}
+
+// Locales returns the locales with messages, sorted
+func (c *Catalog) Locales() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	locales := make([]string, 0, len(c.messages))
+	for locale := range c.messages {
+		locales = append(locales, locale)
+	}
+	slices.Sort(locales)
+
+	return locales
+}
+
+func (c *Catalog) hasLocale(locale string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	_, ok := c.messages[locale]
+	return ok
+}
+
+// Lookup returns the message for key in locale, falling back to the
+// default locale
+func (c *Catalog) Lookup(locale, key string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if message, ok := c.messages[strings.ToLower(locale)][key]; ok {
+		return message, true
+	}
+
+	message, ok := c.messages[DefaultLocale][key]
+	return message, ok
+}
+
+// Translate returns the message for key in locale with its placeholders
+// replaced by params. Unknown keys are returned unchanged, so plain text
+// can be passed wherever a key is expected.
+func (c *Catalog) Translate(locale, key string, params Params) string {
+	message, ok := c.Lookup(locale, key)
+	if !ok {
+		message = key
+	}
+
+	if len(params) == 0 {
+		return message
+	}
+
+	pairs := make([]string, 0, len(params)*2)
+	for name, value := range params {
+		pairs = append(pairs, "{"+name+"}", fmt.Sprint(value))
+	}
+
+	return strings.NewReplacer(pairs...).Replace(message)
+}
+
+// Translate translates key with the default catalog
+func Translate(locale, key string, params Params) string {
+	return Default.Translate(locale, key, params)
+}
+
+func mustLoadEmbedded() *Catalog {
+	c := NewCatalog()
+	if err := c.Load(embedded, "locales"); err != nil {
+		panic(err)
+	}
+	return c
+}
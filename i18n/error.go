@@ -0,0 +1,21 @@
+package i18n
+
+// Error is an error whose text is a catalog message, so responses can show it
+// in the client's language. Error returns the default locale's text, for logs
+// and callers that don't localize.
+type Error struct {
+	Message
+}
+
+func NewError(key string, params Params) *Error {
+	return &Error{Message: Message{Key: key, Params: params}}
+}
+
+func (e *Error) Error() string {
+	return e.Localize(DefaultLocale)
+}
+
+// Localize returns the error text in locale
+func (e *Error) Localize(locale string) string {
+	return Translate(locale, e.Key, e.Params)
+}
@@ -0,0 +1,88 @@
+package i18n
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type contextKey string
+
+const localeContextKey = contextKey("locale")
+
+func ContextWithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeContextKey, locale)
+}
+
+// LocaleFromRequest returns the locale stored by middleware.Locale, or
+// matches the Accept-Language header against the default catalog
+func LocaleFromRequest(r *http.Request) string {
+	if locale, ok := r.Context().Value(localeContextKey).(string); ok {
+		return locale
+	}
+
+	return Default.Match(r.Header.Get("Accept-Language"))
+}
+
+// LocaleFromContext returns the locale stored by middleware.Locale, or the
+// default locale outside a request
+func LocaleFromContext(ctx context.Context) string {
+	if locale, ok := ctx.Value(localeContextKey).(string); ok {
+		return locale
+	}
+
+	return DefaultLocale
+}
+
+// Match picks the catalog locale that best fits an Accept-Language header
+// such as "es-EC,es;q=0.9,en;q=0.8". A regional tag like es-EC matches the
+// es messages when there are no es-ec ones.
+func (c *Catalog) Match(acceptLanguage string) string {
+	type languageRange struct {
+		tag string
+		q   float64
+	}
+
+	var ranges []languageRange
+
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+
+		if q > 0 {
+			ranges = append(ranges, languageRange{tag: tag, q: q})
+		}
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].q > ranges[j].q
+	})
+
+	for _, lr := range ranges {
+		if lr.tag == "*" {
+			return DefaultLocale
+		}
+		if c.hasLocale(lr.tag) {
+			return lr.tag
+		}
+		if base, _, ok := strings.Cut(lr.tag, "-"); ok && c.hasLocale(base) {
+			return base
+		}
+	}
+
+	return DefaultLocale
+}
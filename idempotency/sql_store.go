@@ -0,0 +1,125 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// CreateTableSQL creates the table used by SQLStore
+const CreateTableSQL = `
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+	key          text PRIMARY KEY,
+	fingerprint  text NOT NULL,
+	status_code  integer,
+	headers      jsonb,
+	body         bytea,
+	created_at   timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+	expires_at   timestamp(0) with time zone NOT NULL
+)`
+
+// SQLStore keeps idempotency keys in PostgreSQL, typically on
+// config.Application.DataSource, so replays work across instances
+type SQLStore struct {
+	DB *sql.DB
+}
+
+func NewSQLStore(db *sql.DB) *SQLStore {
+	return &SQLStore{DB: db}
+}
+
+func (s *SQLStore) Begin(ctx context.Context, key, fingerprint string, ttl time.Duration) (*Response, error) {
+	query := `
+		DELETE FROM idempotency_keys
+		WHERE key = $1 AND expires_at < NOW()`
+
+	_, err := s.DB.ExecContext(ctx, query, key)
+	if err != nil {
+		return nil, err
+	}
+
+	query = `
+		INSERT INTO idempotency_keys (key, fingerprint, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (key) DO NOTHING`
+
+	result, err := s.DB.ExecContext(ctx, query, key, fingerprint, time.Now().Add(ttl))
+	if err != nil {
+		return nil, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+
+	if rowsAffected == 1 {
+		return nil, nil
+	}
+
+	query = `
+		SELECT fingerprint, status_code, headers, body
+		FROM idempotency_keys
+		WHERE key = $1`
+
+	var (
+		storedFingerprint string
+		statusCode        sql.NullInt64
+		headers           []byte
+		response          Response
+	)
+
+	err = s.DB.QueryRowContext(ctx, query, key).Scan(&storedFingerprint, &statusCode, &headers, &response.Body)
+	if err != nil {
+		// The key expired and was removed between the insert and the select
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInFlight
+		}
+		return nil, err
+	}
+
+	if storedFingerprint != fingerprint {
+		return nil, ErrMismatch
+	}
+
+	if !statusCode.Valid {
+		return nil, ErrInFlight
+	}
+
+	response.StatusCode = int(statusCode.Int64)
+	response.Header = make(http.Header)
+	if len(headers) > 0 {
+		if err := json.Unmarshal(headers, &response.Header); err != nil {
+			return nil, err
+		}
+	}
+
+	return &response, nil
+}
+
+func (s *SQLStore) Complete(ctx context.Context, key string, response *Response) error {
+	headers, err := json.Marshal(response.Header)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE idempotency_keys
+		SET status_code = $2, headers = $3, body = $4
+		WHERE key = $1`
+
+	_, err = s.DB.ExecContext(ctx, query, key, response.StatusCode, headers, response.Body)
+	return err
+}
+
+func (s *SQLStore) Release(ctx context.Context, key string) error {
+	query := `
+		DELETE FROM idempotency_keys
+		WHERE key = $1 AND status_code IS NULL`
+
+	_, err := s.DB.ExecContext(ctx, query, key)
+	return err
+}
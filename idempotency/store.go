@@ -0,0 +1,118 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	ErrInFlight = errors.New("idempotency: request with this key is still in flight")
+	ErrMismatch = errors.New("idempotency: key reused with a different request")
+)
+
+// Response is the first response sent for an idempotency key
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+type Store interface {
+	// Begin claims the key for a request with the given fingerprint. It
+	// returns the stored response if the key was already completed,
+	// ErrInFlight while another request holds the key and ErrMismatch if the
+	// key was used with a different fingerprint. A nil response and nil
+	// error mean the caller now owns the key.
+	Begin(ctx context.Context, key, fingerprint string, ttl time.Duration) (*Response, error)
+	// Complete stores the response for a key claimed with Begin
+	Complete(ctx context.Context, key string, response *Response) error
+	// Release gives up a claimed key without storing a response, so the
+	// request can be retried
+	Release(ctx context.Context, key string) error
+}
+
+type memoryEntry struct {
+	fingerprint string
+	response    *Response
+	expiresAt   time.Time
+}
+
+type MemoryStore struct {
+	mu       sync.Mutex
+	entries  map[string]*memoryEntry
+	purgedAt time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]*memoryEntry)}
+}
+
+func (s *MemoryStore) Begin(ctx context.Context, key, fingerprint string, ttl time.Duration) (*Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.purge(now)
+
+	entry, exists := s.entries[key]
+	if exists && now.After(entry.expiresAt) {
+		delete(s.entries, key)
+		exists = false
+	}
+
+	if !exists {
+		s.entries[key] = &memoryEntry{
+			fingerprint: fingerprint,
+			expiresAt:   now.Add(ttl),
+		}
+		return nil, nil
+	}
+
+	if entry.fingerprint != fingerprint {
+		return nil, ErrMismatch
+	}
+
+	if entry.response == nil {
+		return nil, ErrInFlight
+	}
+
+	return entry.response, nil
+}
+
+func (s *MemoryStore) Complete(ctx context.Context, key string, response *Response) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, exists := s.entries[key]; exists {
+		entry.response = response
+	}
+
+	return nil
+}
+
+func (s *MemoryStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, exists := s.entries[key]; exists && entry.response == nil {
+		delete(s.entries, key)
+	}
+
+	return nil
+}
+
+func (s *MemoryStore) purge(now time.Time) {
+	if now.Sub(s.purgedAt) < time.Minute {
+		return
+	}
+	s.purgedAt = now
+
+	for key, entry := range s.entries {
+		if now.After(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+type contextKey string
+
+const loggerContextKey = contextKey("logger")
+
+// contextLoggers keeps both forms of the logger. The *Logger is built on the
+// first ZapFromContext call and then reused, so requests that only log with
+// slog never build one.
+type contextLoggers struct {
+	slog    *slog.Logger
+	zapOnce sync.Once
+	zap     *Logger
+}
+
+func (c *contextLoggers) zapLogger() *Logger {
+	c.zapOnce.Do(func() {
+		if c.zap == nil {
+			c.zap = NewLoggerFromSlog(c.slog)
+		}
+	})
+	return c.zap
+}
+
+// NewContext returns a copy of ctx carrying l, so handlers, repositories and
+// event handlers further down can log with the same fields without being
+// passed the logger
+func NewContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey, &contextLoggers{slog: l})
+}
+
+// NewZapContext is NewContext for a *Logger, which ZapFromContext then
+// returns as it is
+func NewZapContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey, &contextLoggers{slog: l.Slog(), zap: l})
+}
+
+// FromContext returns the logger stored by NewContext or
+// middleware.RequestLogger, or slog.Default() if there is none
+func FromContext(ctx context.Context) *slog.Logger {
+	if l, ok := Lookup(ctx); ok {
+		return l
+	}
+
+	return slog.Default()
+}
+
+// Lookup is FromContext reporting whether ctx carries a logger, for code
+// with its own fallback
+func Lookup(ctx context.Context) (*slog.Logger, bool) {
+	if loggers, ok := ctx.Value(loggerContextKey).(*contextLoggers); ok {
+		return loggers.slog, true
+	}
+	return nil, false
+}
+
+// ZapFromContext is FromContext for code that logs with a *Logger. Without
+// a logger in ctx it wraps slog.Default().
+func ZapFromContext(ctx context.Context) *Logger {
+	if loggers, ok := ctx.Value(loggerContextKey).(*contextLoggers); ok {
+		return loggers.zapLogger()
+	}
+
+	return NewLoggerFromSlog(slog.Default())
+}
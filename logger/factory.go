@@ -1,5 +1,7 @@
 package logger
 
+import "log/slog"
+
 func NewDevelopmentLogger(serviceName string) (*Logger, error) {
 	config := LoggerConfig{
 		Level:       "debug",
@@ -28,4 +30,25 @@ func NewTestLogger(serviceName string) (*Logger, error) {
 		Encoding:    "console",
 	}
 	return NewLogger(config)
-} 
\ No newline at end of file
+}
+
+// NewDevelopmentSlogLogger is NewDevelopmentLogger as a *slog.Logger, with
+// the same caller and error stack traces
+func NewDevelopmentSlogLogger(serviceName string) (*slog.Logger, error) {
+	return slogOf(NewDevelopmentLogger(serviceName))
+}
+
+func NewProductionSlogLogger(serviceName string) (*slog.Logger, error) {
+	return slogOf(NewProductionLogger(serviceName))
+}
+
+func NewTestSlogLogger(serviceName string) (*slog.Logger, error) {
+	return slogOf(NewTestLogger(serviceName))
+}
+
+func slogOf(l *Logger, err error) (*slog.Logger, error) {
+	if err != nil {
+		return nil, err
+	}
+	return l.Slog(), nil
+}
@@ -0,0 +1,249 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"slices"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SlogHandler is a slog.Handler that writes through a zap core, so records
+// logged with *slog.Logger get the zap encoder, output, level and fields
+// (service, environment, ...) of the logger the core came from
+type SlogHandler struct {
+	core   zapcore.Core
+	opts   SlogHandlerOptions
+	groups []string
+}
+
+// SlogHandlerOptions are the zap.Logger options a zapcore.Core doesn't carry
+type SlogHandlerOptions struct {
+	AddCaller bool
+	// CallerSkip skips that many frames above the slog call, for logging
+	// helpers that should report their own caller
+	CallerSkip int
+	// AddStacktrace records a stack trace for entries it enables, nil for
+	// none
+	AddStacktrace zapcore.LevelEnabler
+}
+
+// NewSlogHandler uses the options of NewLogger: caller added, and a stack
+// trace from the error level up
+func NewSlogHandler(core zapcore.Core) *SlogHandler {
+	return NewSlogHandlerWithOptions(core, SlogHandlerOptions{
+		AddCaller:     true,
+		AddStacktrace: zapcore.ErrorLevel,
+	})
+}
+
+func NewSlogHandlerWithOptions(core zapcore.Core, opts SlogHandlerOptions) *SlogHandler {
+	return &SlogHandler{core: core, opts: opts}
+}
+
+// Slog returns a *slog.Logger that writes through this logger, for
+// config.Application.Logger and the rest of the module
+func (l *Logger) Slog() *slog.Logger {
+	return slog.New(NewSlogHandler(l.Core()))
+}
+
+// NewSlogLogger builds a zap logger from config and returns it as a
+// *slog.Logger
+func NewSlogLogger(config LoggerConfig) (*slog.Logger, error) {
+	l, err := NewLogger(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.Slog(), nil
+}
+
+func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.core.Enabled(zapLevel(level))
+}
+
+func (h *SlogHandler) Handle(_ context.Context, record slog.Record) error {
+	entry := zapcore.Entry{
+		Level:   zapLevel(record.Level),
+		Time:    record.Time,
+		Message: record.Message,
+	}
+
+	checked := h.core.Check(entry, nil)
+	if checked == nil {
+		return nil
+	}
+
+	addStack := h.opts.AddStacktrace != nil && h.opts.AddStacktrace.Enabled(entry.Level)
+	if record.PC != 0 && (h.opts.AddCaller || addStack) {
+		pcs := []uintptr{record.PC}
+		if h.opts.CallerSkip > 0 || addStack {
+			pcs = callersFrom(record.PC, h.opts.CallerSkip)
+		}
+
+		if h.opts.AddCaller {
+			frame, _ := runtime.CallersFrames(pcs[:1]).Next()
+			checked.Entry.Caller = zapcore.EntryCaller{
+				Defined:  true,
+				PC:       frame.PC,
+				File:     frame.File,
+				Line:     frame.Line,
+				Function: frame.Function,
+			}
+		}
+		if addStack {
+			checked.Entry.Stack = formatStack(pcs)
+		}
+	}
+
+	fields := make([]zap.Field, 0, record.NumAttrs()+len(h.groups))
+	record.Attrs(func(attr slog.Attr) bool {
+		if field, ok := attrField(attr); ok {
+			fields = append(fields, field)
+		}
+		return true
+	})
+
+	// Groups opened by WithGroup are only written when they hold something
+	if len(fields) > 0 {
+		fields = append(namespaces(h.groups), fields...)
+	}
+
+	checked.Write(fields...)
+	return nil
+}
+
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make([]zap.Field, 0, len(attrs))
+	for _, attr := range attrs {
+		if field, ok := attrField(attr); ok {
+			fields = append(fields, field)
+		}
+	}
+
+	if len(fields) == 0 {
+		return h
+	}
+
+	return &SlogHandler{core: h.core.With(append(namespaces(h.groups), fields...)), opts: h.opts}
+}
+
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	return &SlogHandler{core: h.core, opts: h.opts, groups: append(slices.Clip(h.groups), name)}
+}
+
+// callersFrom returns the current goroutine's stack starting skip frames
+// above pc, the slog call. If pc isn't on the stack, e.g. because the record
+// is handled on another goroutine, only pc is returned.
+func callersFrom(pc uintptr, skip int) []uintptr {
+	pcs := make([]uintptr, 64)
+	pcs = pcs[:runtime.Callers(1, pcs)]
+
+	i := slices.Index(pcs, pc)
+	if i < 0 {
+		return []uintptr{pc}
+	}
+
+	return pcs[min(i+skip, len(pcs)-1):]
+}
+
+// formatStack writes frames the way zap does
+func formatStack(pcs []uintptr) string {
+	var b strings.Builder
+
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+		fmt.Fprintf(&b, "%s\n\t%s:%d", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+
+	return b.String()
+}
+
+func namespaces(groups []string) []zap.Field {
+	fields := make([]zap.Field, len(groups))
+	for i, group := range groups {
+		fields[i] = zap.Namespace(group)
+	}
+	return fields
+}
+
+func attrField(attr slog.Attr) (zap.Field, bool) {
+	value := attr.Value.Resolve()
+
+	switch value.Kind() {
+	case slog.KindGroup:
+		group := value.Group()
+		if len(group) == 0 {
+			return zap.Field{}, false
+		}
+		if attr.Key == "" {
+			return zap.Inline(attrGroup(group)), true
+		}
+		return zap.Object(attr.Key, attrGroup(group)), true
+	case slog.KindString:
+		return zap.String(attr.Key, value.String()), attr.Key != ""
+	case slog.KindInt64:
+		return zap.Int64(attr.Key, value.Int64()), attr.Key != ""
+	case slog.KindUint64:
+		return zap.Uint64(attr.Key, value.Uint64()), attr.Key != ""
+	case slog.KindFloat64:
+		return zap.Float64(attr.Key, value.Float64()), attr.Key != ""
+	case slog.KindBool:
+		return zap.Bool(attr.Key, value.Bool()), attr.Key != ""
+	case slog.KindDuration:
+		return zap.Duration(attr.Key, value.Duration()), attr.Key != ""
+	case slog.KindTime:
+		return zap.Time(attr.Key, value.Time()), attr.Key != ""
+	default:
+		if err, ok := value.Any().(error); ok {
+			return zap.NamedError(attr.Key, err), attr.Key != ""
+		}
+		return zap.Any(attr.Key, value.Any()), attr.Key != ""
+	}
+}
+
+type attrGroup []slog.Attr
+
+func (g attrGroup) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, attr := range g {
+		if field, ok := attrField(attr); ok {
+			field.AddTo(enc)
+		}
+	}
+	return nil
+}
+
+// zapLevel maps slog levels onto zap's: both scales put Info at 0, with
+// four slog steps per zap step. Levels above Error stay at Error so a
+// record never triggers zap's panic or exit behaviour.
+func zapLevel(level slog.Level) zapcore.Level {
+	switch {
+	case level < slog.LevelInfo:
+		return zapcore.DebugLevel
+	case level < slog.LevelWarn:
+		return zapcore.InfoLevel
+	case level < slog.LevelError:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.ErrorLevel
+	}
+}
+
+func slogLevel(level zapcore.Level) slog.Level {
+	return slog.Level(int(level) * 4)
+}
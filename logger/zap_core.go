@@ -0,0 +1,90 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SlogCore is a zapcore.Core that writes through a slog.Handler, so code
+// that takes a *Logger can log to the service's *slog.Logger
+type SlogCore struct {
+	handler slog.Handler
+}
+
+func NewSlogCore(handler slog.Handler) *SlogCore {
+	return &SlogCore{handler: handler}
+}
+
+// NewLoggerFromSlog wraps a *slog.Logger in a *Logger with the same
+// handler, attributes and level, and the caller and error stack traces of
+// NewLogger
+func NewLoggerFromSlog(l *slog.Logger) *Logger {
+	return &Logger{Logger: zap.New(NewSlogCore(l.Handler()), zap.AddCaller(), zap.AddStacktrace(zapcore.ErrorLevel))}
+}
+
+func (c *SlogCore) Enabled(level zapcore.Level) bool {
+	return c.handler.Enabled(context.Background(), slogLevel(level))
+}
+
+func (c *SlogCore) With(fields []zapcore.Field) zapcore.Core {
+	attrs := fieldAttrs(fields)
+	if len(attrs) == 0 {
+		return c
+	}
+
+	return &SlogCore{handler: c.handler.WithAttrs(attrs)}
+}
+
+func (c *SlogCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *SlogCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	record := slog.NewRecord(entry.Time, slogLevel(entry.Level), entry.Message, entry.Caller.PC)
+	record.AddAttrs(fieldAttrs(fields)...)
+
+	if entry.LoggerName != "" {
+		record.AddAttrs(slog.String("logger", entry.LoggerName))
+	}
+	if entry.Stack != "" {
+		record.AddAttrs(slog.String("stacktrace", entry.Stack))
+	}
+
+	return c.handler.Handle(context.Background(), record)
+}
+
+func (c *SlogCore) Sync() error {
+	return nil
+}
+
+// fieldAttrs encodes zap fields with zap's own map encoder, which handles
+// every field type, then turns the result into attributes sorted by key
+func fieldAttrs(fields []zapcore.Field) []slog.Attr {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	enc := zapcore.NewMapObjectEncoder()
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+
+	keys := make([]string, 0, len(enc.Fields))
+	for key := range enc.Fields {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	attrs := make([]slog.Attr, len(keys))
+	for i, key := range keys {
+		attrs[i] = slog.Any(key, enc.Fields[key])
+	}
+	return attrs
+}
@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"database/sql"
+	"runtime"
+)
+
+type goCollector struct{}
+
+// NewGoCollector reports goroutines, memory and GC statistics of the Go
+// runtime
+func NewGoCollector() Collector {
+	return goCollector{}
+}
+
+func (goCollector) Collect() []Family {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+
+	gauge := func(name, help string, value float64) Family {
+		return Family{Name: name, Help: help, Type: TypeGauge, Samples: []Sample{{Value: value}}}
+	}
+	counter := func(name, help string, value float64) Family {
+		return Family{Name: name, Help: help, Type: TypeCounter, Samples: []Sample{{Value: value}}}
+	}
+
+	return []Family{
+		{
+			Name:    "go_info",
+			Help:    "Information about the Go environment.",
+			Type:    TypeGauge,
+			Samples: []Sample{{Labels: []Label{{Name: "version", Value: runtime.Version()}}, Value: 1}},
+		},
+		gauge("go_goroutines", "Number of goroutines that currently exist.", float64(runtime.NumGoroutine())),
+		gauge("go_memstats_alloc_bytes", "Number of bytes allocated and still in use.", float64(stats.Alloc)),
+		counter("go_memstats_alloc_bytes_total", "Total number of bytes allocated, even if freed.", float64(stats.TotalAlloc)),
+		gauge("go_memstats_sys_bytes", "Number of bytes obtained from system.", float64(stats.Sys)),
+		gauge("go_memstats_heap_inuse_bytes", "Number of heap bytes that are in use.", float64(stats.HeapInuse)),
+		gauge("go_memstats_heap_objects", "Number of allocated objects.", float64(stats.HeapObjects)),
+		counter("go_gc_cycles_total", "Number of completed GC cycles.", float64(stats.NumGC)),
+		counter("go_gc_pause_seconds_total", "Total time spent in GC stop-the-world pauses.", float64(stats.PauseTotalNs)/1e9),
+	}
+}
+
+type dbStatsCollector struct {
+	db   *sql.DB
+	name string
+}
+
+// NewDBStatsCollector reports the sql.DBStats of a connection pool, labelled
+// with the given name
+func NewDBStatsCollector(db *sql.DB, name string) Collector {
+	return &dbStatsCollector{db: db, name: name}
+}
+
+func (c *dbStatsCollector) Collect() []Family {
+	stats := c.db.Stats()
+	labels := []Label{{Name: "db", Value: c.name}}
+
+	family := func(name, help, typ string, value float64) Family {
+		return Family{Name: name, Help: help, Type: typ, Samples: []Sample{{Labels: labels, Value: value}}}
+	}
+
+	return []Family{
+		family("go_sql_max_open_connections", "Maximum number of open connections to the database.", TypeGauge, float64(stats.MaxOpenConnections)),
+		family("go_sql_open_connections", "The number of established connections both in use and idle.", TypeGauge, float64(stats.OpenConnections)),
+		family("go_sql_in_use_connections", "The number of connections currently in use.", TypeGauge, float64(stats.InUse)),
+		family("go_sql_idle_connections", "The number of idle connections.", TypeGauge, float64(stats.Idle)),
+		family("go_sql_wait_count_total", "The total number of connections waited for.", TypeCounter, float64(stats.WaitCount)),
+		family("go_sql_wait_duration_seconds_total", "The total time blocked waiting for a new connection.", TypeCounter, stats.WaitDuration.Seconds()),
+		family("go_sql_max_idle_closed_total", "The total number of connections closed due to SetMaxIdleConns.", TypeCounter, float64(stats.MaxIdleClosed)),
+		family("go_sql_max_idle_time_closed_total", "The total number of connections closed due to SetConnMaxIdleTime.", TypeCounter, float64(stats.MaxIdleTimeClosed)),
+		family("go_sql_max_lifetime_closed_total", "The total number of connections closed due to SetConnMaxLifetime.", TypeCounter, float64(stats.MaxLifetimeClosed)),
+	}
+}
@@ -0,0 +1,177 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// atomicFloat is a float64 updated without locks
+type atomicFloat struct {
+	bits atomic.Uint64
+}
+
+func (f *atomicFloat) Add(delta float64) {
+	for {
+		old := f.bits.Load()
+		updated := math.Float64bits(math.Float64frombits(old) + delta)
+		if f.bits.CompareAndSwap(old, updated) {
+			return
+		}
+	}
+}
+
+func (f *atomicFloat) Set(value float64) {
+	f.bits.Store(math.Float64bits(value))
+}
+
+func (f *atomicFloat) Load() float64 {
+	return math.Float64frombits(f.bits.Load())
+}
+
+type Counter struct {
+	value atomicFloat
+}
+
+func (c *Counter) Inc() {
+	c.value.Add(1)
+}
+
+// Add increases the counter; negative values are ignored
+func (c *Counter) Add(delta float64) {
+	if delta > 0 {
+		c.value.Add(delta)
+	}
+}
+
+type CounterVec struct {
+	vec *vec
+}
+
+func (v *CounterVec) With(values ...string) *Counter {
+	return v.vec.get(values, func() any { return &Counter{} }).(*Counter)
+}
+
+func (v *CounterVec) Collect() []Family {
+	family := Family{Name: v.vec.name, Help: v.vec.help, Type: TypeCounter}
+	for _, c := range v.vec.sorted() {
+		family.Samples = append(family.Samples, Sample{
+			Labels: c.labels,
+			Value:  c.metric.(*Counter).value.Load(),
+		})
+	}
+	return []Family{family}
+}
+
+type Gauge struct {
+	value atomicFloat
+}
+
+func (g *Gauge) Set(value float64) {
+	g.value.Set(value)
+}
+
+func (g *Gauge) Add(delta float64) {
+	g.value.Add(delta)
+}
+
+func (g *Gauge) Inc() {
+	g.value.Add(1)
+}
+
+func (g *Gauge) Dec() {
+	g.value.Add(-1)
+}
+
+type GaugeVec struct {
+	vec *vec
+}
+
+func (v *GaugeVec) With(values ...string) *Gauge {
+	return v.vec.get(values, func() any { return &Gauge{} }).(*Gauge)
+}
+
+func (v *GaugeVec) Collect() []Family {
+	family := Family{Name: v.vec.name, Help: v.vec.help, Type: TypeGauge}
+	for _, c := range v.vec.sorted() {
+		family.Samples = append(family.Samples, Sample{
+			Labels: c.labels,
+			Value:  c.metric.(*Gauge).value.Load(),
+		})
+	}
+	return []Family{family}
+}
+
+type Histogram struct {
+	mu      sync.Mutex
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+func (h *Histogram) Observe(value float64) {
+	i := sort.SearchFloat64s(h.buckets, value)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if i < len(h.counts) {
+		h.counts[i]++
+	}
+	h.sum += value
+	h.count++
+}
+
+type HistogramVec struct {
+	vec     *vec
+	buckets []float64
+}
+
+func (v *HistogramVec) With(values ...string) *Histogram {
+	return v.vec.get(values, func() any {
+		return &Histogram{
+			buckets: v.buckets,
+			counts:  make([]uint64, len(v.buckets)),
+		}
+	}).(*Histogram)
+}
+
+func (v *HistogramVec) Collect() []Family {
+	family := Family{Name: v.vec.name, Help: v.vec.help, Type: TypeHistogram}
+
+	for _, c := range v.vec.sorted() {
+		h := c.metric.(*Histogram)
+
+		h.mu.Lock()
+		counts := append([]uint64(nil), h.counts...)
+		sum, count := h.sum, h.count
+		h.mu.Unlock()
+
+		var cumulative uint64
+		for i, upperBound := range h.buckets {
+			cumulative += counts[i]
+			family.Samples = append(family.Samples, Sample{
+				Suffix: "_bucket",
+				Labels: withLabel(c.labels, "le", strconv.FormatFloat(upperBound, 'g', -1, 64)),
+				Value:  float64(cumulative),
+			})
+		}
+
+		family.Samples = append(family.Samples,
+			Sample{Suffix: "_bucket", Labels: withLabel(c.labels, "le", "+Inf"), Value: float64(count)},
+			Sample{Suffix: "_sum", Labels: c.labels, Value: sum},
+			Sample{Suffix: "_count", Labels: c.labels, Value: float64(count)},
+		)
+	}
+
+	return []Family{family}
+}
+
+func withLabel(labels []Label, name, value string) []Label {
+	extended := make([]Label, len(labels), len(labels)+1)
+	copy(extended, labels)
+	return append(extended, Label{Name: name, Value: value})
+}
@@ -0,0 +1,298 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	TypeCounter   = "counter"
+	TypeGauge     = "gauge"
+	TypeHistogram = "histogram"
+)
+
+// DefaultBuckets suit HTTP latencies measured in seconds
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type Label struct {
+	Name  string
+	Value string
+}
+
+type Sample struct {
+	Suffix string
+	Labels []Label
+	Value  float64
+}
+
+// Family is a named group of samples as exposed to Prometheus
+type Family struct {
+	Name    string
+	Help    string
+	Type    string
+	Samples []Sample
+}
+
+// Collector produces metric families at scrape time
+type Collector interface {
+	Collect() []Family
+}
+
+type Registry struct {
+	mu         sync.Mutex
+	names      map[string]Collector
+	collectors []Collector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]Collector)}
+}
+
+// Register adds a collector that produces its own families, such as the Go
+// runtime collector
+func (reg *Registry) Register(c Collector) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.collectors = append(reg.collectors, c)
+}
+
+// getOrRegister returns the metric already registered under name, so
+// middleware installed on several route groups shares its metrics
+func getOrRegister[T Collector](reg *Registry, name string, create func() T) T {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	if existing, ok := reg.names[name]; ok {
+		metric, ok := existing.(T)
+		if !ok {
+			panic(fmt.Sprintf("metrics: %s is already registered with a different type", name))
+		}
+		return metric
+	}
+
+	metric := create()
+	reg.names[name] = metric
+	reg.collectors = append(reg.collectors, metric)
+	return metric
+}
+
+func (reg *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return getOrRegister(reg, name, func() *CounterVec {
+		return &CounterVec{vec: newVec(name, help, labels)}
+	})
+}
+
+func (reg *Registry) NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return getOrRegister(reg, name, func() *GaugeVec {
+		return &GaugeVec{vec: newVec(name, help, labels)}
+	})
+}
+
+func (reg *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	return getOrRegister(reg, name, func() *HistogramVec {
+		if buckets == nil {
+			buckets = DefaultBuckets
+		}
+		return &HistogramVec{vec: newVec(name, help, labels), buckets: buckets}
+	})
+}
+
+func (reg *Registry) NewCounterFunc(name, help string, fn func() float64) {
+	getOrRegister(reg, name, func() *funcMetric {
+		return &funcMetric{name: name, help: help, typ: TypeCounter, fn: fn}
+	})
+}
+
+func (reg *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	getOrRegister(reg, name, func() *funcMetric {
+		return &funcMetric{name: name, help: help, typ: TypeGauge, fn: fn}
+	})
+}
+
+// WritePrometheus writes every metric in the Prometheus text exposition format
+func (reg *Registry) WritePrometheus(w io.Writer) error {
+	reg.mu.Lock()
+	collectors := append([]Collector(nil), reg.collectors...)
+	reg.mu.Unlock()
+
+	// Collectors may report the same family, e.g. one DB stats collector per
+	// pool, and Prometheus rejects a family written twice
+	var families []Family
+	index := make(map[string]int)
+	for _, c := range collectors {
+		for _, family := range c.Collect() {
+			if i, ok := index[family.Name]; ok {
+				families[i].Samples = append(families[i].Samples, family.Samples...)
+				continue
+			}
+			index[family.Name] = len(families)
+			families = append(families, family)
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+
+	for _, family := range families {
+		fmt.Fprintf(bw, "# HELP %s %s\n", family.Name, escapeHelp(family.Help))
+		fmt.Fprintf(bw, "# TYPE %s %s\n", family.Name, family.Type)
+
+		for _, sample := range family.Samples {
+			bw.WriteString(family.Name)
+			bw.WriteString(sample.Suffix)
+			writeLabels(bw, sample.Labels)
+			bw.WriteByte(' ')
+			bw.WriteString(formatValue(sample.Value))
+			bw.WriteByte('\n')
+		}
+	}
+
+	return bw.Flush()
+}
+
+// Handler serves the registry for Prometheus to scrape, usually on the admin
+// listener
+func (reg *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		reg.WritePrometheus(w)
+	})
+}
+
+func writeLabels(bw *bufio.Writer, labels []Label) {
+	if len(labels) == 0 {
+		return
+	}
+
+	bw.WriteByte('{')
+	for i, label := range labels {
+		if i > 0 {
+			bw.WriteByte(',')
+		}
+		bw.WriteString(label.Name)
+		bw.WriteString(`="`)
+		bw.WriteString(escapeLabelValue(label.Value))
+		bw.WriteByte('"')
+	}
+	bw.WriteByte('}')
+}
+
+var (
+	helpReplacer  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(help string) string {
+	return helpReplacer.Replace(help)
+}
+
+func escapeLabelValue(value string) string {
+	return labelReplacer.Replace(value)
+}
+
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
+
+// vec keeps one metric per distinct combination of label values
+type vec struct {
+	name     string
+	help     string
+	labels   []string
+	mu       sync.RWMutex
+	children map[string]*child
+}
+
+type child struct {
+	labels []Label
+	metric any
+}
+
+func newVec(name, help string, labels []string) *vec {
+	return &vec{
+		name:     name,
+		help:     help,
+		labels:   labels,
+		children: make(map[string]*child),
+	}
+}
+
+func (v *vec) get(values []string, create func() any) any {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", v.name, len(v.labels), len(values)))
+	}
+
+	key := strings.Join(values, "\xff")
+
+	v.mu.RLock()
+	c, ok := v.children[key]
+	v.mu.RUnlock()
+	if ok {
+		return c.metric
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if c, ok := v.children[key]; ok {
+		return c.metric
+	}
+
+	labels := make([]Label, len(values))
+	for i, value := range values {
+		labels[i] = Label{Name: v.labels[i], Value: value}
+	}
+
+	c = &child{labels: labels, metric: create()}
+	v.children[key] = c
+	return c.metric
+}
+
+// sorted returns the children ordered by label values so scrapes are stable
+func (v *vec) sorted() []*child {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	keys := make([]string, 0, len(v.children))
+	for key := range v.children {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	sorted := make([]*child, len(keys))
+	for i, key := range keys {
+		sorted[i] = v.children[key]
+	}
+	return sorted
+}
+
+type funcMetric struct {
+	name string
+	help string
+	typ  string
+	fn   func() float64
+}
+
+func (m *funcMetric) Collect() []Family {
+	return []Family{{
+		Name:    m.name,
+		Help:    m.help,
+		Type:    m.typ,
+		Samples: []Sample{{Value: m.fn()}},
+	}}
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/leninner/shared/auth"
+	"github.com/leninner/shared/exception"
+)
+
+func Authenticate(verifier *auth.Verifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Authorization")
+
+			authorizationHeader := r.Header.Get("Authorization")
+			if authorizationHeader == "" {
+				r = auth.ContextSetPrincipal(r, auth.AnonymousPrincipal)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			headerParts := strings.Split(authorizationHeader, " ")
+			if len(headerParts) != 2 || headerParts[0] != "Bearer" {
+				exception.InvalidAuthenticationTokenResponse(w, r)
+				return
+			}
+
+			principal, err := verifier.Authenticate(headerParts[1])
+			if err != nil {
+				exception.InvalidAuthenticationTokenResponse(w, r)
+				return
+			}
+
+			r = auth.ContextSetPrincipal(r, principal)
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func RequireAuthenticated(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal := auth.ContextGetPrincipal(r)
+
+		if principal.IsAnonymous() {
+			exception.AuthenticationRequiredResponse(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func RequireActivated(next http.Handler) http.Handler {
+	fn := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal := auth.ContextGetPrincipal(r)
+
+		if !principal.Activated {
+			exception.InactiveAccountResponse(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+
+	return RequireAuthenticated(fn)
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/leninner/shared/auth"
+	"github.com/leninner/shared/domain/valueobject"
+	"github.com/leninner/shared/exception"
+)
+
+// OwnerFunc returns the customer that owns the resource addressed by the
+// request, or auth.ErrResourceNotFound if there is no such resource
+type OwnerFunc func(r *http.Request) (valueobject.CustomerID, error)
+
+func RequirePermission(source auth.PermissionSource, code string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal := auth.ContextGetPrincipal(r)
+
+			permissions, err := source.PermissionsFor(r.Context(), principal)
+			if err != nil {
+				exception.ServerErrorResponse(w, r, err)
+				return
+			}
+
+			if !permissions.Include(code) {
+				exception.NotPermittedResponse(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+
+		return RequireActivated(fn)
+	}
+}
+
+// RequireOwnership lets the request through when the principal owns the
+// resource, or when it holds the override permission (e.g. "orders:read:any"
+// for staff). An empty override disables the bypass.
+func RequireOwnership(source auth.PermissionSource, owner OwnerFunc, override string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal := auth.ContextGetPrincipal(r)
+
+			if override != "" {
+				permissions, err := source.PermissionsFor(r.Context(), principal)
+				if err != nil {
+					exception.ServerErrorResponse(w, r, err)
+					return
+				}
+
+				if permissions.Include(override) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			customerID, err := owner(r)
+			if err != nil {
+				switch {
+				case errors.Is(err, auth.ErrResourceNotFound):
+					exception.NotFoundResponse(w, r)
+				default:
+					exception.ServerErrorResponse(w, r, err)
+				}
+				return
+			}
+
+			if !principal.Owns(customerID) {
+				exception.NotPermittedResponse(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+
+		return RequireActivated(fn)
+	}
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import "net/http"
+
+type Middleware func(http.Handler) http.Handler
+
+// Chain composes middleware so that NewChain(a, b, c).Then(h) is equivalent
+// to a(b(c(h))). Chains are immutable; Append and Extend return new chains.
+type Chain struct {
+	middlewares []Middleware
+}
+
+func NewChain(middlewares ...Middleware) Chain {
+	return Chain{middlewares: append([]Middleware(nil), middlewares...)}
+}
+
+func (c Chain) Append(middlewares ...Middleware) Chain {
+	newMiddlewares := make([]Middleware, 0, len(c.middlewares)+len(middlewares))
+	newMiddlewares = append(newMiddlewares, c.middlewares...)
+	newMiddlewares = append(newMiddlewares, middlewares...)
+
+	return Chain{middlewares: newMiddlewares}
+}
+
+func (c Chain) Extend(chain Chain) Chain {
+	return c.Append(chain.middlewares...)
+}
+
+func (c Chain) Then(h http.Handler) http.Handler {
+	if h == nil {
+		h = http.DefaultServeMux
+	}
+
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		h = c.middlewares[i](h)
+	}
+
+	return h
+}
+
+func (c Chain) ThenFunc(fn http.HandlerFunc) http.Handler {
+	if fn == nil {
+		return c.Then(nil)
+	}
+
+	return c.Then(fn)
+}
@@ -0,0 +1,335 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	encodingGzip = "gzip"
+	encodingZstd = "zstd"
+)
+
+type CompressOptions struct {
+	// MinSize is the smallest body, in bytes, worth compressing
+	MinSize int
+	// SkipContentTypes lists media types, or prefixes ending in "/", that
+	// are already compressed
+	SkipContentTypes []string
+}
+
+var defaultSkipContentTypes = []string{
+	"image/",
+	"video/",
+	"audio/",
+	"application/gzip",
+	"application/zip",
+	"application/zstd",
+	"application/x-7z-compressed",
+	"application/x-rar-compressed",
+	"font/woff",
+	"font/woff2",
+}
+
+var (
+	gzipWriterPool = sync.Pool{
+		New: func() any {
+			return gzip.NewWriter(io.Discard)
+		},
+	}
+	zstdEncoderPool = sync.Pool{
+		New: func() any {
+			enc, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+			return enc
+		},
+	}
+)
+
+// Compress encodes responses with zstd or gzip depending on the client's
+// Accept-Encoding header. Bodies under 1KB and already-compressed content
+// types are sent as they are, unless the client refuses identity.
+func Compress() Middleware {
+	return CompressWith(CompressOptions{
+		MinSize:          1024,
+		SkipContentTypes: defaultSkipContentTypes,
+	})
+}
+
+func CompressWith(opts CompressOptions) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			encoding, identity := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+			if encoding == "" || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{
+				ResponseWriter: w,
+				encoding:       encoding,
+				opts:           opts,
+				force:          !identity,
+			}
+
+			next.ServeHTTP(cw, r)
+
+			// Not deferred: while a panic is unwinding, the held back status
+			// must stay uncommitted so RecoverPanic can still send its 500
+			cw.Close()
+		})
+	}
+}
+
+// negotiateEncoding picks the accepted encoding with the highest q-value,
+// preferring zstd over gzip on ties. "*" covers the codings not listed on
+// their own, and identity reports whether an uncompressed body is still
+// acceptable, which "identity;q=0" or "*;q=0" rule out.
+func negotiateEncoding(acceptEncoding string) (encoding string, identity bool) {
+	qualities := make(map[string]float64)
+
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding == "" {
+			continue
+		}
+
+		q, ok := qValue(params)
+		if !ok {
+			continue
+		}
+		qualities[coding] = q
+	}
+
+	quality := func(coding string) (float64, bool) {
+		if q, ok := qualities[coding]; ok {
+			return q, true
+		}
+		q, ok := qualities["*"]
+		return q, ok
+	}
+
+	bestQ := 0.0
+	for _, coding := range []string{encodingZstd, encodingGzip} {
+		if q, ok := quality(coding); ok && q > bestQ {
+			encoding, bestQ = coding, q
+		}
+	}
+
+	identity = true
+	if q, ok := quality("identity"); ok && q <= 0 {
+		identity = false
+	}
+
+	return encoding, identity
+}
+
+// qValue reads the q parameter of an Accept-Encoding element, 1 when it has
+// none
+func qValue(params string) (float64, bool) {
+	for _, param := range strings.Split(params, ";") {
+		name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+		if !strings.EqualFold(strings.TrimSpace(name), "q") {
+			continue
+		}
+		q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || q < 0 || q > 1 {
+			return 0, false
+		}
+		return q, true
+	}
+
+	return 1, true
+}
+
+// compressWriter holds back the first MinSize bytes so small responses can be
+// sent uncompressed, then streams the rest through a pooled encoder. With
+// force set, because the client refused identity, every body is compressed.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	opts     CompressOptions
+	force    bool
+
+	status      int
+	wroteHeader bool
+	decided     bool
+	buf         []byte
+	encoder     io.WriteCloser
+}
+
+func (cw *compressWriter) WriteHeader(status int) {
+	if cw.wroteHeader {
+		return
+	}
+
+	// Informational responses such as 103 Early Hints go out as they are and
+	// don't stand for the final status
+	if status >= 100 && status < 200 && status != http.StatusSwitchingProtocols {
+		cw.ResponseWriter.WriteHeader(status)
+		return
+	}
+
+	cw.wroteHeader = true
+	cw.status = status
+
+	// Responses without a body are never compressed
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified {
+		cw.decide(false)
+	}
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+
+	if !cw.decided {
+		cw.buf = append(cw.buf, p...)
+		if len(cw.buf) < cw.opts.MinSize && !cw.force {
+			return len(p), nil
+		}
+
+		buffered := cw.buf
+		cw.buf = nil
+		cw.decide(cw.compressible(buffered))
+		if _, err := cw.writeBody(buffered); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+
+	return cw.writeBody(p)
+}
+
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		if !cw.wroteHeader {
+			cw.WriteHeader(http.StatusOK)
+		}
+		buffered := cw.buf
+		cw.buf = nil
+		cw.decide(cw.compressible(buffered))
+		cw.writeBody(buffered)
+	}
+
+	if flusher, ok := cw.encoder.(interface{ Flush() error }); ok {
+		flusher.Flush()
+	}
+	if flusher, ok := cw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// Close flushes the encoder, or the held back bytes when the body stayed
+// under MinSize, and returns the encoder to its pool
+func (cw *compressWriter) Close() error {
+	if !cw.decided {
+		if !cw.wroteHeader {
+			return nil
+		}
+		buffered := cw.buf
+		cw.buf = nil
+		cw.decide(cw.force && len(buffered) > 0 && cw.compressible(buffered))
+		if _, err := cw.writeBody(buffered); err != nil {
+			return err
+		}
+	}
+
+	if cw.encoder == nil {
+		return nil
+	}
+
+	err := cw.encoder.Close()
+	switch enc := cw.encoder.(type) {
+	case *gzip.Writer:
+		enc.Reset(io.Discard)
+		gzipWriterPool.Put(enc)
+	case *zstd.Encoder:
+		enc.Reset(nil)
+		zstdEncoderPool.Put(enc)
+	}
+	cw.encoder = nil
+
+	return err
+}
+
+func (cw *compressWriter) decide(compress bool) {
+	cw.decided = true
+
+	if compress {
+		h := cw.Header()
+		h.Set("Content-Encoding", cw.encoding)
+		h.Del("Content-Length")
+
+		switch cw.encoding {
+		case encodingZstd:
+			enc := zstdEncoderPool.Get().(*zstd.Encoder)
+			enc.Reset(cw.ResponseWriter)
+			cw.encoder = enc
+		default:
+			enc := gzipWriterPool.Get().(*gzip.Writer)
+			enc.Reset(cw.ResponseWriter)
+			cw.encoder = enc
+		}
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.status)
+}
+
+func (cw *compressWriter) writeBody(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	if cw.encoder != nil {
+		return cw.encoder.Write(p)
+	}
+
+	return cw.ResponseWriter.Write(p)
+}
+
+func (cw *compressWriter) compressible(body []byte) bool {
+	h := cw.Header()
+
+	if h.Get("Content-Encoding") != "" {
+		return false
+	}
+
+	// Sniff before compressing, net/http would otherwise sniff the encoded bytes
+	contentType := h.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(body)
+		h.Set("Content-Type", contentType)
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	if cw.force {
+		return true
+	}
+
+	for _, skip := range cw.opts.SkipContentTypes {
+		if strings.HasSuffix(skip, "/") && strings.HasPrefix(mediaType, skip) || mediaType == skip {
+			return false
+		}
+	}
+
+	return true
+}
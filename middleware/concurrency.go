@@ -0,0 +1,278 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/leninner/shared/exception"
+)
+
+type Priority int
+
+const (
+	// PriorityLow requests are shed as soon as the limit is reached
+	PriorityLow Priority = iota
+	// PriorityNormal requests wait up to MaxWait for a free slot
+	PriorityNormal
+	// PriorityCritical requests, such as health checks and payment
+	// callbacks, are never limited
+	PriorityCritical
+)
+
+// CriticalPaths classifies requests under the given path prefixes, e.g.
+// "/healthcheck" or "/v1/payments/callback", as PriorityCritical
+func CriticalPaths(prefixes ...string) func(r *http.Request) Priority {
+	return func(r *http.Request) Priority {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(r.URL.Path, prefix) {
+				return PriorityCritical
+			}
+		}
+		return PriorityNormal
+	}
+}
+
+// LimitAlgorithm decides how many requests may be in flight. Its methods are
+// called with the limiter's lock held.
+type LimitAlgorithm interface {
+	Limit() int
+	Observe(latency time.Duration, inFlight int)
+}
+
+type fixedLimit int
+
+// FixedLimit always allows n requests in flight
+func FixedLimit(n int) LimitAlgorithm {
+	return fixedLimit(n)
+}
+
+func (l fixedLimit) Limit() int {
+	return int(l)
+}
+
+func (l fixedLimit) Observe(latency time.Duration, inFlight int) {}
+
+// AdaptiveLimit adjusts the limit from observed latency: it shrinks when
+// requests get slower than the long-term average and grows again while they
+// stay close to it. Zero fields of a struct literal get the defaults of
+// NewAdaptiveLimit once it's passed to NewConcurrencyLimiter, starting at 20
+// between 1 and 1000.
+type AdaptiveLimit struct {
+	MinLimit int
+	MaxLimit int
+	// Tolerance is how much slower than the long-term average requests may
+	// get before the limit shrinks, e.g. 1.5
+	Tolerance float64
+	// Smoothing weights each new estimate, between 0 and 1
+	Smoothing float64
+
+	limit   float64
+	longRTT float64
+}
+
+func NewAdaptiveLimit(initial, min, max int) *AdaptiveLimit {
+	l := &AdaptiveLimit{
+		MinLimit: min,
+		MaxLimit: max,
+		limit:    float64(initial),
+	}
+	l.setDefaults()
+	return l
+}
+
+func (l *AdaptiveLimit) setDefaults() {
+	if l.MinLimit <= 0 {
+		l.MinLimit = 1
+	}
+	if l.MaxLimit <= 0 {
+		l.MaxLimit = 1000
+	}
+	l.MaxLimit = max(l.MaxLimit, l.MinLimit)
+	if l.Tolerance <= 0 {
+		l.Tolerance = 1.5
+	}
+	if l.Smoothing <= 0 || l.Smoothing > 1 {
+		l.Smoothing = 0.2
+	}
+	if l.limit <= 0 {
+		l.limit = 20
+	}
+	l.limit = math.Max(float64(l.MinLimit), math.Min(float64(l.MaxLimit), l.limit))
+}
+
+func (l *AdaptiveLimit) Limit() int {
+	return int(l.limit)
+}
+
+func (l *AdaptiveLimit) Observe(latency time.Duration, inFlight int) {
+	rtt := float64(latency)
+	if rtt <= 0 {
+		return
+	}
+
+	if l.longRTT == 0 {
+		l.longRTT = rtt
+	} else {
+		l.longRTT = l.longRTT*0.99 + rtt*0.01
+	}
+
+	// Don't grow the limit while the service isn't using it
+	if float64(inFlight) < l.limit/2 {
+		return
+	}
+
+	gradient := math.Max(0.5, math.Min(1.0, l.Tolerance*l.longRTT/rtt))
+	newLimit := l.limit*gradient + math.Sqrt(l.limit)
+	newLimit = l.limit*(1-l.Smoothing) + newLimit*l.Smoothing
+
+	l.limit = math.Max(float64(l.MinLimit), math.Min(float64(l.MaxLimit), newLimit))
+}
+
+type ConcurrencyOptions struct {
+	Algorithm LimitAlgorithm
+	// MaxWait is how long a request may queue before it is shed
+	MaxWait time.Duration
+	// MaxQueue caps the number of queued requests, 100 when zero
+	MaxQueue int
+	// RetryAfter is sent to shed clients
+	RetryAfter time.Duration
+	// Priority classifies requests; nil treats every request as normal
+	Priority func(r *http.Request) Priority
+}
+
+type ConcurrencyLimiter struct {
+	mu       sync.Mutex
+	opts     ConcurrencyOptions
+	inFlight int
+	waiters  []chan struct{}
+}
+
+func NewConcurrencyLimiter(opts ConcurrencyOptions) *ConcurrencyLimiter {
+	if opts.Algorithm == nil {
+		opts.Algorithm = FixedLimit(100)
+	}
+	if adaptive, ok := opts.Algorithm.(*AdaptiveLimit); ok {
+		adaptive.setDefaults()
+	}
+	if opts.MaxQueue <= 0 {
+		opts.MaxQueue = 100
+	}
+	if opts.RetryAfter <= 0 {
+		opts.RetryAfter = time.Second
+	}
+
+	return &ConcurrencyLimiter{opts: opts}
+}
+
+func (l *ConcurrencyLimiter) InFlight() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.inFlight
+}
+
+func (l *ConcurrencyLimiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.opts.Algorithm.Limit()
+}
+
+// LimitConcurrency caps the requests in flight through the limiter, queueing
+// briefly and then shedding with a JSON 503 and Retry-After
+func LimitConcurrency(limiter *ConcurrencyLimiter) Middleware {
+	retryAfter := strconv.Itoa(int(math.Ceil(limiter.opts.RetryAfter.Seconds())))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			priority := PriorityNormal
+			if limiter.opts.Priority != nil {
+				priority = limiter.opts.Priority(r)
+			}
+
+			if priority == PriorityCritical {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !limiter.acquire(r.Context(), priority) {
+				if r.Context().Err() != nil {
+					return
+				}
+
+				w.Header().Set("Retry-After", retryAfter)
+				exception.ServiceUnavailableResponse(w, r)
+				return
+			}
+
+			start := time.Now()
+			defer func() {
+				limiter.release(time.Since(start))
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (l *ConcurrencyLimiter) acquire(ctx context.Context, priority Priority) bool {
+	l.mu.Lock()
+
+	if l.inFlight < l.opts.Algorithm.Limit() {
+		l.inFlight++
+		l.mu.Unlock()
+		return true
+	}
+
+	if priority == PriorityLow || l.opts.MaxWait <= 0 || len(l.waiters) >= l.opts.MaxQueue {
+		l.mu.Unlock()
+		return false
+	}
+
+	ready := make(chan struct{})
+	l.waiters = append(l.waiters, ready)
+	l.mu.Unlock()
+
+	timer := time.NewTimer(l.opts.MaxWait)
+	defer timer.Stop()
+
+	select {
+	case <-ready:
+		return true
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	i := slices.Index(l.waiters, ready)
+	if i >= 0 {
+		l.waiters = slices.Delete(l.waiters, i, i+1)
+		return false
+	}
+
+	// A slot was handed over while we were giving up
+	return true
+}
+
+func (l *ConcurrencyLimiter) release(latency time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.opts.Algorithm.Observe(latency, l.inFlight)
+	l.inFlight--
+
+	// Hand free slots straight to the oldest waiters: none if the limit
+	// shrank, several if it grew
+	for len(l.waiters) > 0 && l.inFlight < l.opts.Algorithm.Limit() {
+		ready := l.waiters[0]
+		l.waiters = l.waiters[1:]
+		l.inFlight++
+		close(ready)
+	}
+}
@@ -0,0 +1,241 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/leninner/shared/config"
+)
+
+var (
+	ErrDumpInProduction = errors.New("request dumps cannot be enabled in production")
+	ErrDumpNotEnabled   = errors.New("request dumps are not enabled, see config.Debug.RequestDumps")
+)
+
+const redacted = "[REDACTED]"
+
+type DumpOptions struct {
+	// Logger receives the dumps, slog.Default() if nil
+	Logger *slog.Logger
+	// MaxBodySize is the largest request or response body logged, in bytes
+	MaxBodySize int
+	// Header, when set, limits dumps to requests that carry it, e.g.
+	// "X-Debug-Dump". Leave empty to dump every request on the route.
+	Header string
+	// RedactHeaders are logged as [REDACTED]
+	RedactHeaders []string
+	// RedactFields are JSON, form and query parameter names, matched
+	// case-insensitively at any depth, whose values are logged as [REDACTED]
+	RedactFields []string
+}
+
+func DefaultDumpOptions() DumpOptions {
+	return DumpOptions{
+		MaxBodySize:   16 * 1024,
+		Header:        "X-Debug-Dump",
+		RedactHeaders: []string{"Authorization", "Cookie", "Set-Cookie", "Proxy-Authorization", "X-Api-Key"},
+		RedactFields:  []string{"password", "card_number", "cardNumber", "cvv", "cvc", "pan", "secret", "token", "access_token", "refresh_token"},
+	}
+}
+
+// DumpRequests logs request and response headers and bodies for debugging
+// integrations. Apply it to the route groups being debugged. It is only
+// available when config.Debug.RequestDumps is set, and never in production.
+func DumpRequests(cfg config.Config, opts DumpOptions) (Middleware, error) {
+	if !cfg.Debug.RequestDumps {
+		return nil, ErrDumpNotEnabled
+	}
+	if isProduction(cfg.Env) {
+		return nil, ErrDumpInProduction
+	}
+
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+
+	fields := make(map[string]bool, len(opts.RedactFields))
+	for _, field := range opts.RedactFields {
+		fields[strings.ToLower(field)] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if opts.Header != "" && r.Header.Get(opts.Header) == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Read one byte past the limit to know whether the body was cut
+			requestBody, err := io.ReadAll(io.LimitReader(r.Body, int64(opts.MaxBodySize)+1))
+			if err != nil {
+				requestBody = nil
+			}
+			r.Body = readCloser{io.MultiReader(bytes.NewReader(requestBody), r.Body), r.Body}
+
+			dw := &dumpWriter{ResponseWriter: w, limit: opts.MaxBodySize + 1}
+			start := time.Now()
+
+			next.ServeHTTP(dw, r)
+
+			if dw.status == 0 {
+				dw.status = http.StatusOK
+			}
+
+			opts.Logger.InfoContext(r.Context(), "http dump",
+				"method", r.Method,
+				"uri", redactURI(r.URL, fields),
+				"request_headers", redactHeaders(r.Header, opts.RedactHeaders),
+				"request_body", dumpBody(r.Header.Get("Content-Type"), requestBody, opts.MaxBodySize, fields),
+				"status", dw.status,
+				"response_headers", redactHeaders(w.Header(), opts.RedactHeaders),
+				"response_body", dumpBody(w.Header().Get("Content-Type"), dw.body.Bytes(), opts.MaxBodySize, fields),
+				"duration", time.Since(start),
+			)
+		})
+	}, nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+type dumpWriter struct {
+	http.ResponseWriter
+	status int
+	limit  int
+	body   bytes.Buffer
+}
+
+func (dw *dumpWriter) WriteHeader(status int) {
+	if dw.status == 0 {
+		dw.status = status
+	}
+	dw.ResponseWriter.WriteHeader(status)
+}
+
+func (dw *dumpWriter) Write(p []byte) (int, error) {
+	if dw.status == 0 {
+		dw.status = http.StatusOK
+	}
+	if remaining := dw.limit - dw.body.Len(); remaining > 0 {
+		dw.body.Write(p[:min(len(p), remaining)])
+	}
+	return dw.ResponseWriter.Write(p)
+}
+
+func (dw *dumpWriter) Flush() {
+	if flusher, ok := dw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (dw *dumpWriter) Unwrap() http.ResponseWriter {
+	return dw.ResponseWriter
+}
+
+func redactHeaders(h http.Header, names []string) map[string]string {
+	dump := make(map[string]string, len(h))
+	for key, values := range h {
+		dump[key] = strings.Join(values, ", ")
+	}
+
+	for _, name := range names {
+		key := http.CanonicalHeaderKey(name)
+		if _, exists := dump[key]; exists {
+			dump[key] = redacted
+		}
+	}
+
+	return dump
+}
+
+// redactURI redacts the query parameters named like sensitive fields, e.g.
+// ?access_token=...
+func redactURI(u *url.URL, fields map[string]bool) string {
+	if u.RawQuery == "" {
+		return u.RequestURI()
+	}
+
+	values, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		return u.EscapedPath() + "?[omitted: invalid query]"
+	}
+
+	return u.EscapedPath() + "?" + redactValues(values, fields).Encode()
+}
+
+func redactValues(values url.Values, fields map[string]bool) url.Values {
+	for key := range values {
+		if fields[strings.ToLower(key)] {
+			values[key] = []string{redacted}
+		}
+	}
+	return values
+}
+
+// dumpBody returns the body with sensitive fields redacted. Bodies that can't
+// be redacted reliably, because they were cut at the size limit or aren't
+// JSON or form data, such as plain text, are replaced by a placeholder.
+func dumpBody(contentType string, body []byte, limit int, fields map[string]bool) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	if len(body) > limit {
+		return fmt.Sprintf("[omitted: larger than %d bytes]", limit)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		var value any
+		if err := json.Unmarshal(body, &value); err != nil {
+			return "[omitted: invalid JSON]"
+		}
+		out, err := json.Marshal(redactJSON(value, fields))
+		if err != nil {
+			return "[omitted: invalid JSON]"
+		}
+		return string(out)
+
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return "[omitted: invalid form]"
+		}
+		return redactValues(values, fields).Encode()
+
+	default:
+		return fmt.Sprintf("[omitted: %d bytes of %s]", len(body), contentType)
+	}
+}
+
+func redactJSON(value any, fields map[string]bool) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, child := range v {
+			if fields[strings.ToLower(key)] {
+				v[key] = redacted
+				continue
+			}
+			v[key] = redactJSON(child, fields)
+		}
+	case []any:
+		for i, child := range v {
+			v[i] = redactJSON(child, fields)
+		}
+	}
+
+	return value
+}
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/leninner/shared/exception"
+	"github.com/leninner/shared/utils"
+)
+
+// ETag buffers successful GET and HEAD responses, tags them with a hash of the
+// body and answers matching If-None-Match requests with 304. Handlers that set
+// their own ETag header (e.g. from the entity version) keep it. HEAD responses
+// are only tagged by the handler: their body, if any, may not be the GET one.
+func ETag(weak bool) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			bw := &bufferedWriter{header: make(http.Header)}
+			next.ServeHTTP(bw, r)
+
+			if bw.status == 0 {
+				bw.status = http.StatusOK
+			}
+
+			dst := w.Header()
+			for key, values := range bw.header {
+				dst[key] = values
+			}
+
+			if bw.status == http.StatusOK {
+				etag := dst.Get("ETag")
+				if etag == "" && r.Method == http.MethodGet {
+					etag = utils.ComputeETag(bw.body.Bytes(), weak)
+				}
+
+				if etag != "" && utils.SetETag(w, r, etag) {
+					utils.NotModified(w)
+					return
+				}
+			}
+
+			w.WriteHeader(bw.status)
+			w.Write(bw.body.Bytes())
+		})
+	}
+}
+
+// RequireIfMatch rejects unsafe requests that don't carry an If-Match header
+// with 428, so updates can't silently overwrite concurrent changes. Handlers
+// still compare the header against the entity version, with
+// exception.CheckIfMatch.
+func RequireIfMatch(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isUnsafeMethod(r.Method) && r.Method != http.MethodPost && !utils.HasIfMatch(r) {
+			exception.PreconditionRequiredResponse(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+type bufferedWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (bw *bufferedWriter) Header() http.Header {
+	return bw.header
+}
+
+func (bw *bufferedWriter) WriteHeader(status int) {
+	if bw.status == 0 {
+		bw.status = status
+	}
+}
+
+func (bw *bufferedWriter) Write(p []byte) (int, error) {
+	if bw.status == 0 {
+		bw.status = http.StatusOK
+	}
+	return bw.body.Write(p)
+}
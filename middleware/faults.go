@@ -0,0 +1,177 @@
+package middleware
+
+import (
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/leninner/shared/config"
+	"github.com/leninner/shared/exception"
+	"github.com/leninner/shared/utils"
+)
+
+var (
+	ErrFaultsInProduction = errors.New("fault injection cannot be enabled in production")
+	ErrFaultsNotEnabled   = errors.New("fault injection is not enabled, see config.Debug.FaultInjection")
+)
+
+// FaultRule describes a fault and the requests it applies to. Empty match
+// fields match every request.
+type FaultRule struct {
+	Name string `json:"name"`
+	// Route matches the route pattern (e.g. "/v1/orders/:id") or a path prefix
+	Route  string `json:"route"`
+	Method string `json:"method"`
+	// Header, and optionally HeaderValue, must be present on the request,
+	// e.g. "X-Chaos" so only test traffic is affected
+	Header      string `json:"header"`
+	HeaderValue string `json:"header_value"`
+	// Percentage of matching requests affected, 0 means all of them
+	Percentage float64 `json:"percentage"`
+
+	LatencyMS int  `json:"latency_ms"`
+	Status    int  `json:"status"`
+	Abort     bool `json:"abort"`
+}
+
+func (rule FaultRule) matches(r *http.Request) bool {
+	if rule.Route != "" {
+		route := utils.ContextGetRoute(r)
+		if route != rule.Route && !strings.HasPrefix(r.URL.Path, rule.Route) {
+			return false
+		}
+	}
+
+	if rule.Method != "" && !strings.EqualFold(rule.Method, r.Method) {
+		return false
+	}
+
+	if rule.Header != "" {
+		value := r.Header.Get(rule.Header)
+		if value == "" || rule.HeaderValue != "" && value != rule.HeaderValue {
+			return false
+		}
+	}
+
+	return rule.Percentage <= 0 || rand.Float64()*100 < rule.Percentage
+}
+
+// FaultInjector holds the fault rules for resilience testing. It is only
+// created when config.Debug.FaultInjection is set, and never in production.
+type FaultInjector struct {
+	mu    sync.RWMutex
+	rules []FaultRule
+}
+
+func NewFaultInjector(cfg config.Config) (*FaultInjector, error) {
+	if !cfg.Debug.FaultInjection {
+		return nil, ErrFaultsNotEnabled
+	}
+	if isProduction(cfg.Env) {
+		return nil, ErrFaultsInProduction
+	}
+
+	return &FaultInjector{}, nil
+}
+
+// isProduction treats an unset environment as production, so debug tools
+// stay off when ENV is forgotten
+func isProduction(env string) bool {
+	env = strings.TrimSpace(env)
+	return env == "" || strings.EqualFold(env, "production") || strings.EqualFold(env, "prod")
+}
+
+func (f *FaultInjector) Rules() []FaultRule {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return append([]FaultRule(nil), f.rules...)
+}
+
+func (f *FaultInjector) SetRules(rules []FaultRule) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules = append([]FaultRule(nil), rules...)
+}
+
+// AdminHandler lists the rules on GET, replaces them on PUT with a body like
+// {"rules": [...]} and removes them all on DELETE
+func (f *FaultInjector) AdminHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			var input struct {
+				Rules []FaultRule `json:"rules"`
+			}
+
+			err := utils.ReadJSON(w, r, &input)
+			if err != nil {
+				exception.BadRequestResponse(w, r, err)
+				return
+			}
+
+			for _, rule := range input.Rules {
+				if rule.Status != 0 && (rule.Status < 400 || rule.Status > 599) {
+					exception.FailedValidationResponse(w, r, map[string]string{"status": "must be an error status between 400 and 599"})
+					return
+				}
+			}
+
+			f.SetRules(input.Rules)
+		case http.MethodDelete:
+			f.SetRules(nil)
+		default:
+			exception.MethodNotAllowedResponse(w, r)
+			return
+		}
+
+		err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{"rules": f.Rules()}, nil)
+		if err != nil {
+			exception.ServerErrorResponse(w, r, err)
+		}
+	})
+}
+
+// InjectFaults applies the first matching rule: it waits for the configured
+// latency, then aborts the connection or answers with the error status.
+// Place it inside route groups so rules can match on route patterns.
+func InjectFaults(f *FaultInjector) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			f.mu.RLock()
+			rules := f.rules
+			f.mu.RUnlock()
+
+			for _, rule := range rules {
+				if !rule.matches(r) {
+					continue
+				}
+
+				if rule.LatencyMS > 0 {
+					select {
+					case <-time.After(time.Duration(rule.LatencyMS) * time.Millisecond):
+					case <-r.Context().Done():
+						return
+					}
+				}
+
+				if rule.Abort {
+					panic(http.ErrAbortHandler)
+				}
+
+				if rule.Status != 0 {
+					w.Header().Set("X-Fault-Injected", rule.Name)
+					exception.ErrorResponse(w, r, rule.Status, strings.ToLower(http.StatusText(rule.Status)))
+					return
+				}
+
+				break
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/leninner/shared/auth"
+	"github.com/leninner/shared/exception"
+	"github.com/leninner/shared/i18n"
+	"github.com/leninner/shared/idempotency"
+)
+
+const maxIdempotencyKeyLength = 255
+
+// Idempotency replays the first response sent for an Idempotency-Key header
+// on unsafe requests. Keys are scoped to the authenticated principal and the
+// request path. Server errors are not stored, so those requests can be
+// retried with the same key.
+func Idempotency(store idempotency.Store, ttl time.Duration) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get("Idempotency-Key")
+			if key == "" || !isUnsafeMethod(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if len(key) > maxIdempotencyKeyLength {
+				exception.BadRequestResponse(w, r, i18n.NewError("errors.idempotency_key_too_long", i18n.Params{"max": maxIdempotencyKeyLength}))
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 1_048_576))
+			if err != nil {
+				var maxBytesErr *http.MaxBytesError
+				if errors.As(err, &maxBytesErr) {
+					exception.RequestTooLargeResponse(w, r, maxBytesErr.Limit)
+					return
+				}
+				exception.BadRequestResponse(w, r, err)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			key = scopedIdempotencyKey(r, key)
+			fingerprint := requestFingerprint(r, body)
+
+			stored, err := store.Begin(r.Context(), key, fingerprint, ttl)
+			switch {
+			case errors.Is(err, idempotency.ErrInFlight):
+				w.Header().Set("Retry-After", "1")
+				exception.IdempotencyKeyInUseResponse(w, r)
+				return
+			case errors.Is(err, idempotency.ErrMismatch):
+				exception.IdempotencyKeyMismatchResponse(w, r)
+				return
+			case err != nil:
+				exception.ServerErrorResponse(w, r, err)
+				return
+			case stored != nil:
+				replayResponse(w, stored)
+				return
+			}
+
+			// Headers set before this point, such as X-Request-ID and
+			// traceresponse, belong to this request and must not be replayed
+			outer := w.Header().Clone()
+			rec := &recordingWriter{ResponseWriter: w}
+			ctx := context.WithoutCancel(r.Context())
+			completed := false
+
+			defer func() {
+				if !completed {
+					store.Release(ctx, key)
+				}
+			}()
+
+			next.ServeHTTP(rec, r)
+
+			if rec.status == 0 {
+				rec.status = http.StatusOK
+			}
+
+			if rec.status >= http.StatusInternalServerError {
+				return
+			}
+
+			err = store.Complete(ctx, key, &idempotency.Response{
+				StatusCode: rec.status,
+				Header:     handlerHeaders(outer, w.Header()),
+				Body:       rec.body.Bytes(),
+			})
+			if err != nil {
+				exception.LogError(r, err)
+				return
+			}
+			completed = true
+		})
+	}
+}
+
+func isUnsafeMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+func scopedIdempotencyKey(r *http.Request, key string) string {
+	subject := ""
+	if principal, ok := auth.PrincipalFromContext(r.Context()); ok {
+		subject = principal.Subject
+	}
+
+	return subject + ":" + r.URL.Path + ":" + key
+}
+
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method)
+	io.WriteString(h, "\n")
+	io.WriteString(h, r.URL.RequestURI())
+	io.WriteString(h, "\n")
+	h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// handlerHeaders returns the headers of header that were added or changed
+// since outer was taken
+func handlerHeaders(outer, header http.Header) http.Header {
+	added := make(http.Header)
+	for key, values := range header {
+		if !slices.Equal(outer[key], values) {
+			added[key] = slices.Clone(values)
+		}
+	}
+
+	return added
+}
+
+func replayResponse(w http.ResponseWriter, stored *idempotency.Response) {
+	for key, values := range stored.Header {
+		w.Header()[key] = values
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+
+	w.WriteHeader(stored.StatusCode)
+	w.Write(stored.Body)
+}
+
+// recordingWriter passes the response through while keeping a copy of the
+// status and body
+type recordingWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rw *recordingWriter) WriteHeader(status int) {
+	if rw.status == 0 {
+		rw.status = status
+	}
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *recordingWriter) Write(p []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	rw.body.Write(p)
+	return rw.ResponseWriter.Write(p)
+}
+
+func (rw *recordingWriter) Flush() {
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (rw *recordingWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
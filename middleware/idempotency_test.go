@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/leninner/shared/idempotency"
+)
+
+func TestIdempotencyReplaysOnlyHandlerHeaders(t *testing.T) {
+	var calls atomic.Int32
+	handler := RequestID(Idempotency(idempotency.NewMemoryStore(), time.Minute)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.Header().Set("Location", "/orders/1")
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":1}`))
+		}),
+	))
+
+	send := func(requestID string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{}`))
+		r.Header.Set("Idempotency-Key", "key-1")
+		r.Header.Set(RequestIDHeader, requestID)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	first := send("first-request")
+	second := send("second-request")
+
+	if calls.Load() != 1 {
+		t.Fatalf("handler called %d times, want 1", calls.Load())
+	}
+	if got := second.Header().Get("Idempotent-Replayed"); got != "true" {
+		t.Fatalf("Idempotent-Replayed = %q, want true", got)
+	}
+	if got := second.Header().Get(RequestIDHeader); got != "second-request" {
+		t.Errorf("replayed X-Request-ID = %q, want second-request", got)
+	}
+	if got := second.Header().Get("Location"); got != "/orders/1" {
+		t.Errorf("replayed Location = %q, want /orders/1", got)
+	}
+	if second.Code != first.Code || second.Body.String() != first.Body.String() {
+		t.Errorf("replay = %d %q, want %d %q", second.Code, second.Body, first.Code, first.Body)
+	}
+}
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/leninner/shared/i18n"
+)
+
+// Locale picks the response language from Accept-Language, stores it in the
+// request context for exception responses and validators, and announces it
+// in Content-Language
+func Locale(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		locale := i18n.Default.Match(r.Header.Get("Accept-Language"))
+
+		w.Header().Add("Vary", "Accept-Language")
+		w.Header().Set("Content-Language", locale)
+
+		ctx := i18n.ContextWithLocale(r.Context(), locale)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,189 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/leninner/shared/config"
+	"github.com/leninner/shared/exception"
+	"github.com/leninner/shared/utils"
+)
+
+type Mode string
+
+const (
+	ModeNormal      Mode = "normal"
+	ModeReadOnly    Mode = "read-only"
+	ModeMaintenance Mode = "maintenance"
+)
+
+func ParseMode(value string) (Mode, error) {
+	switch mode := Mode(strings.ToLower(strings.TrimSpace(value))); mode {
+	case ModeNormal, ModeReadOnly, ModeMaintenance:
+		return mode, nil
+	case "":
+		return ModeNormal, nil
+	default:
+		return "", fmt.Errorf("unknown service mode %q", value)
+	}
+}
+
+// ModeSwitch holds the service mode. It can be changed at runtime through
+// SetMode, the admin handler, a signal or a configuration reload.
+type ModeSwitch struct {
+	mu         sync.RWMutex
+	mode       Mode
+	retryAfter time.Duration
+	allowlist  []string
+}
+
+func NewModeSwitch(cfg config.MaintenanceConfig) (*ModeSwitch, error) {
+	s := &ModeSwitch{}
+
+	if err := s.Reload(cfg); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *ModeSwitch) Mode() Mode {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.mode
+}
+
+func (s *ModeSwitch) SetMode(mode Mode) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mode = mode
+}
+
+// Reload applies a freshly loaded configuration
+func (s *ModeSwitch) Reload(cfg config.MaintenanceConfig) error {
+	mode, err := ParseMode(cfg.Mode)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.mode = mode
+	s.retryAfter = cfg.RetryAfter
+	s.allowlist = append([]string(nil), cfg.Allowlist...)
+
+	return nil
+}
+
+// WatchSignal toggles between the given mode and normal mode every time sig
+// is received, until ctx is done. For example SIGUSR1 for read-only mode
+// and SIGUSR2 for maintenance mode.
+func (s *ModeSwitch) WatchSignal(ctx context.Context, sig os.Signal, mode Mode) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, sig)
+
+	go func() {
+		defer signal.Stop(signals)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-signals:
+				s.mu.Lock()
+				if s.mode == mode {
+					s.mode = ModeNormal
+				} else {
+					s.mode = mode
+				}
+				s.mu.Unlock()
+			}
+		}
+	}()
+}
+
+// AdminHandler reports the current mode on GET and changes it on PUT with a
+// body like {"mode": "read-only"}. Mount it on the admin listener or behind
+// RequirePermission.
+func (s *ModeSwitch) AdminHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			var input struct {
+				Mode string `json:"mode"`
+			}
+
+			err := utils.ReadJSON(w, r, &input)
+			if err != nil {
+				exception.BadRequestResponse(w, r, err)
+				return
+			}
+
+			mode, err := ParseMode(input.Mode)
+			if err != nil {
+				exception.FailedValidationResponse(w, r, map[string]string{"mode": "must be normal, read-only or maintenance"})
+				return
+			}
+
+			s.SetMode(mode)
+		default:
+			exception.MethodNotAllowedResponse(w, r)
+			return
+		}
+
+		err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{"mode": s.Mode()}, nil)
+		if err != nil {
+			exception.ServerErrorResponse(w, r, err)
+		}
+	})
+}
+
+func (s *ModeSwitch) allowed(path string) bool {
+	for _, prefix := range s.allowlist {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Maintenance rejects unsafe requests in read-only mode and every request
+// outside the allowlist in maintenance mode, both with a JSON 503
+func Maintenance(s *ModeSwitch) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s.mu.RLock()
+			mode := s.mode
+			allowed := s.allowed(r.URL.Path)
+			retryAfter := s.retryAfter
+			s.mu.RUnlock()
+
+			switch {
+			case mode == ModeNormal || allowed:
+			case mode == ModeReadOnly:
+				if isUnsafeMethod(r.Method) {
+					exception.ReadOnlyModeResponse(w, r)
+					return
+				}
+			case mode == ModeMaintenance:
+				if retryAfter > 0 {
+					w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				}
+				exception.MaintenanceModeResponse(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
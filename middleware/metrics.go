@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/leninner/shared/metrics"
+	"github.com/leninner/shared/utils"
+)
+
+// Metrics records request counts and latencies labelled by route pattern, and
+// the number of requests in flight. Requests that match no route are reported
+// under route="unmatched".
+func Metrics(reg *metrics.Registry) Middleware {
+	requests := reg.NewCounterVec("http_requests_total", "Total number of HTTP requests.", "method", "route", "status")
+	duration := reg.NewHistogramVec("http_request_duration_seconds", "HTTP request latency in seconds.", metrics.DefaultBuckets, "method", "route", "status")
+	// Not labelled by route: the route is only known once the router ran
+	inFlight := reg.NewGaugeVec("http_requests_in_flight", "Number of HTTP requests being served.").With()
+	reg.NewCounterFunc("http_panics_recovered_total", "Total number of panics recovered while serving requests.", func() float64 {
+		return float64(utils.PanicsRecovered())
+	})
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			inFlight.Inc()
+			defer inFlight.Dec()
+
+			sw := &statusWriter{ResponseWriter: w}
+			start := time.Now()
+
+			defer func() {
+				// A panic becomes a 500 once RecoverPanic handles it
+				pv := recover()
+				if pv != nil {
+					sw.status = http.StatusInternalServerError
+				}
+				if sw.status == 0 {
+					sw.status = http.StatusOK
+				}
+
+				route := routeLabel(utils.ContextGetRoute(r))
+				status := strconv.Itoa(sw.status/100) + "xx"
+
+				requests.With(r.Method, route, status).Inc()
+				duration.With(r.Method, route, status).Observe(time.Since(start).Seconds())
+
+				if pv != nil {
+					panic(pv)
+				}
+			}()
+
+			next.ServeHTTP(sw, r)
+		})
+	}
+}
+
+func routeLabel(route string) string {
+	if route == "" {
+		return "unmatched"
+	}
+	return route
+}
+
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sw *statusWriter) WriteHeader(status int) {
+	if sw.status == 0 {
+		sw.status = status
+	}
+	sw.ResponseWriter.WriteHeader(status)
+}
+
+func (sw *statusWriter) Write(p []byte) (int, error) {
+	if sw.status == 0 {
+		sw.status = http.StatusOK
+	}
+	return sw.ResponseWriter.Write(p)
+}
+
+func (sw *statusWriter) Flush() {
+	if flusher, ok := sw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (sw *statusWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
@@ -1,21 +1,31 @@
 package middleware
 
 import (
-	"fmt"
 	"net/http"
 
 	"github.com/leninner/shared/exception"
+	"github.com/leninner/shared/reporting"
+	"github.com/leninner/shared/utils"
 )
 
 func RecoverPanic(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Give panic reports the request, its breadcrumbs and its route
+		r = reporting.ContextWithRequest(utils.ContextInitRoute(r))
+
 		defer func() {
 			pv := recover()
 			if pv != nil {
+				// Let net/http abort the response as intended
+				if pv == http.ErrAbortHandler {
+					panic(pv)
+				}
+
+				err := utils.RecoveredPanic(r.Context(), pv)
 
 				w.Header().Set("Connection", "close")
 
-				exception.ServerErrorResponse(w, r, fmt.Errorf("%v", pv))
+				exception.ServerErrorResponse(w, r, err)
 			}
 		}()
 
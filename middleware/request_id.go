@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/leninner/shared/utils"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID keeps the caller's X-Request-ID when it looks sane, or generates
+// a new one, stores it in the request context and echoes it in the response
+// so error responses and logs can be matched with client reports
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set(RequestIDHeader, id)
+
+		next.ServeHTTP(w, utils.ContextSetRequestID(r, id))
+	})
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+
+	for _, c := range id {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func newRequestID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/leninner/shared/auth"
+	"github.com/leninner/shared/logger"
+	"github.com/leninner/shared/tracing"
+	"github.com/leninner/shared/utils"
+)
+
+// RequestLogger stores a logger in the request context, retrieved with
+// logger.FromContext or logger.ZapFromContext, that carries the method, path,
+// request ID, trace and span IDs, route and principal. It enriches base, or
+// the logger already in the context if base is nil. Install it on route
+// groups after RequestID, Trace and Authenticate so all of those are known.
+func RequestLogger(base *slog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			l := base
+			if l == nil {
+				l = logger.FromContext(r.Context())
+			}
+
+			attrs := []any{"method", r.Method, "path", r.URL.Path}
+
+			if id := utils.ContextGetRequestID(r); id != "" {
+				attrs = append(attrs, "request_id", id)
+			}
+
+			if sc, ok := tracing.SpanContextFromContext(r.Context()); ok {
+				attrs = append(attrs, "trace_id", sc.TraceID.String(), "span_id", sc.SpanID.String())
+			}
+
+			if route := utils.ContextGetRoute(r); route != "" {
+				attrs = append(attrs, "route", route)
+			}
+
+			if principal, ok := auth.PrincipalFromContext(r.Context()); ok && !principal.IsAnonymous() {
+				attrs = append(attrs, "principal", principal.Subject)
+			}
+
+			ctx := logger.NewContext(r.Context(), l.With(attrs...))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
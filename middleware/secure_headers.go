@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/leninner/shared/config"
+)
+
+// SecureHeaders sets the security headers described by the policy. Applying
+// it again on a route group replaces the headers for those routes, e.g. a
+// looser Content-Security-Policy for documentation pages:
+//
+//	docsPolicy := cfg.Security
+//	docsPolicy.ContentSecurityPolicy = "default-src 'self'; style-src 'self' 'unsafe-inline'"
+//	docs := rt.Group("/docs", middleware.SecureHeaders(docsPolicy))
+func SecureHeaders(policy config.SecurityConfig) Middleware {
+	hsts := ""
+	if policy.HSTSMaxAge > 0 {
+		hsts = "max-age=" + strconv.FormatInt(int64(policy.HSTSMaxAge.Seconds()), 10)
+		if policy.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		if policy.HSTSPreload {
+			hsts += "; preload"
+		}
+	}
+
+	nosniff := ""
+	if policy.ContentTypeNosniff {
+		nosniff = "nosniff"
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+
+			// HSTS is ignored by browsers over plain HTTP, so only send it on TLS
+			if r.TLS != nil || policy.TrustForwardedProto && forwardedHTTPS(r) {
+				setOrDelete(h, "Strict-Transport-Security", hsts)
+			}
+			setOrDelete(h, "X-Content-Type-Options", nosniff)
+			setOrDelete(h, "X-Frame-Options", policy.FrameOptions)
+			setOrDelete(h, "Referrer-Policy", policy.ReferrerPolicy)
+			setOrDelete(h, "Content-Security-Policy", policy.ContentSecurityPolicy)
+			setOrDelete(h, "Permissions-Policy", policy.PermissionsPolicy)
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// forwardedHTTPS reports whether the proxy closest to the client received the
+// request over https
+func forwardedHTTPS(r *http.Request) bool {
+	proto, _, _ := strings.Cut(r.Header.Get("X-Forwarded-Proto"), ",")
+	return strings.EqualFold(strings.TrimSpace(proto), "https")
+}
+
+func setOrDelete(h http.Header, key, value string) {
+	if value == "" {
+		h.Del(key)
+		return
+	}
+
+	h.Set(key, value)
+}
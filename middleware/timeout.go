@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/leninner/shared/exception"
+	"github.com/leninner/shared/utils"
+)
+
+// Timeout sets a deadline on the request context and answers with a JSON 503
+// if the handler hasn't finished when it fires. Database calls made with
+// r.Context() are cancelled at the same deadline.
+func Timeout(d time.Duration) Middleware {
+	return TimeoutWith(d, exception.ServiceUnavailableResponse)
+}
+
+// TimeoutWith is like Timeout but lets the caller choose the response, e.g.
+// exception.GatewayTimeoutResponse for a 504
+func TimeoutWith(d time.Duration, onTimeout http.HandlerFunc) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+
+			r = r.WithContext(ctx)
+
+			done := make(chan struct{})
+			panicChan := make(chan any, 1)
+			tw := &timeoutWriter{h: make(http.Header)}
+
+			go func() {
+				defer func() {
+					if pv := recover(); pv != nil {
+						if pv != http.ErrAbortHandler {
+							pv = &utils.PanicError{Value: pv, Stack: debug.Stack()}
+						}
+						panicChan <- pv
+					}
+				}()
+
+				next.ServeHTTP(tw, r)
+				close(done)
+			}()
+
+			select {
+			case pv := <-panicChan:
+				// Re-panic on the serving goroutine so RecoverPanic sees it
+				panic(pv)
+
+			case <-done:
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+
+				dst := w.Header()
+				for k, vv := range tw.h {
+					dst[k] = vv
+				}
+				if !tw.wroteHeader {
+					tw.code = http.StatusOK
+				}
+				w.WriteHeader(tw.code)
+				w.Write(tw.wbuf.Bytes())
+
+			case <-ctx.Done():
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+
+				tw.timedOut = true
+				if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					onTimeout(w, r)
+				}
+
+				// The handler is still running. A panic it raises later has
+				// nobody left to re-panic to, so report it from here.
+				reportCtx := context.WithoutCancel(r.Context())
+				go func() {
+					select {
+					case pv := <-panicChan:
+						if pv != http.ErrAbortHandler {
+							utils.RecoveredPanic(reportCtx, pv)
+						}
+					case <-done:
+					}
+				}()
+			}
+		})
+	}
+}
+
+// timeoutWriter buffers the response so nothing reaches the client until the
+// handler finishes, and rejects writes made after the deadline fired
+type timeoutWriter struct {
+	h    http.Header
+	wbuf bytes.Buffer
+
+	mu          sync.Mutex
+	timedOut    bool
+	wroteHeader bool
+	code        int
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.h
+}
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+
+	if !tw.wroteHeader {
+		tw.writeHeaderLocked(http.StatusOK)
+	}
+
+	return tw.wbuf.Write(p)
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+
+	tw.writeHeaderLocked(code)
+}
+
+func (tw *timeoutWriter) writeHeaderLocked(code int) {
+	tw.wroteHeader = true
+	tw.code = code
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/leninner/shared/tracing"
+	"github.com/leninner/shared/utils"
+)
+
+// Trace continues the trace from the caller's traceparent and tracestate
+// headers, or starts a new one, and records a server span for the request.
+// The span is named after the route pattern once the router has matched it,
+// and its context is returned to the caller in the traceresponse header.
+func Trace(tracer *tracing.Tracer) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			if sc, ok := tracing.Extract(r.Header); ok {
+				ctx = tracing.ContextWithRemoteSpanContext(ctx, sc)
+			}
+
+			ctx, span := tracer.Start(ctx, r.Method, tracing.SpanKindServer)
+			r = utils.ContextInitRoute(r.WithContext(ctx))
+
+			w.Header().Set(tracing.TraceresponseHeader, span.SpanContext().Traceparent())
+
+			sw := &statusWriter{ResponseWriter: w}
+
+			defer func() {
+				pv := recover()
+				if pv != nil {
+					sw.status = http.StatusInternalServerError
+					span.SetError(fmt.Errorf("panic: %v", pv))
+				}
+				if sw.status == 0 {
+					sw.status = http.StatusOK
+				}
+
+				route := utils.ContextGetRoute(r)
+				if route != "" {
+					span.SetName(r.Method + " " + route)
+					span.SetAttribute("http.route", route)
+				}
+				span.SetAttribute("http.method", r.Method)
+				span.SetAttribute("http.target", r.URL.Path)
+				span.SetAttribute("http.status_code", sw.status)
+				span.End()
+
+				if pv != nil {
+					panic(pv)
+				}
+			}()
+
+			next.ServeHTTP(sw, r)
+		})
+	}
+}
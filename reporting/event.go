@@ -0,0 +1,184 @@
+package reporting
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/leninner/shared/auth"
+	"github.com/leninner/shared/tracing"
+	"github.com/leninner/shared/utils"
+)
+
+const maxBreadcrumbs = 30
+
+// Event is one reported error with everything known about where it happened
+type Event struct {
+	Timestamp   time.Time    `json:"timestamp"`
+	Service     string       `json:"service,omitempty"`
+	Environment string       `json:"environment,omitempty"`
+	Fingerprint string       `json:"fingerprint"`
+	Message     string       `json:"message"`
+	Type        string       `json:"type"`
+	Causes      []string     `json:"causes,omitempty"`
+	Stack       string       `json:"stack,omitempty"`
+	Panic       bool         `json:"panic,omitempty"`
+	Request     *Request     `json:"request,omitempty"`
+	TraceID     string       `json:"trace_id,omitempty"`
+	Breadcrumbs []Breadcrumb `json:"breadcrumbs,omitempty"`
+	// Suppressed is the number of events with the same fingerprint dropped by
+	// rate limiting since the previous report
+	Suppressed int `json:"suppressed,omitempty"`
+}
+
+type Request struct {
+	Method    string `json:"method"`
+	URL       string `json:"url"`
+	Route     string `json:"route,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+	ClientIP  string `json:"client_ip,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+	Principal string `json:"principal,omitempty"`
+}
+
+// Breadcrumb is something that happened before the error, such as a call to
+// another service or a state change
+type Breadcrumb struct {
+	Timestamp time.Time `json:"timestamp"`
+	Category  string    `json:"category"`
+	Message   string    `json:"message"`
+}
+
+type contextKey string
+
+const scopeContextKey = contextKey("reportingScope")
+
+// scope is shared by every handler of a request, so breadcrumbs added deep
+// in the chain are visible to RecoverPanic
+type scope struct {
+	mu          sync.Mutex
+	request     *http.Request
+	breadcrumbs []Breadcrumb
+}
+
+// ContextWithRequest attaches the request and an empty breadcrumb trail to
+// its context. RecoverPanic calls it, so handlers can add breadcrumbs without
+// extra middleware.
+func ContextWithRequest(r *http.Request) *http.Request {
+	if _, ok := r.Context().Value(scopeContextKey).(*scope); ok {
+		return r
+	}
+
+	ctx := context.WithValue(r.Context(), scopeContextKey, &scope{request: r})
+	return r.WithContext(ctx)
+}
+
+// AddBreadcrumb records an event on the current request's trail. The trail
+// keeps the last 30 breadcrumbs and is sent with any error reported for the
+// request.
+func AddBreadcrumb(ctx context.Context, category, message string) {
+	s, ok := ctx.Value(scopeContextKey).(*scope)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.breadcrumbs) == maxBreadcrumbs {
+		s.breadcrumbs = append(s.breadcrumbs[:0], s.breadcrumbs[1:]...)
+	}
+	s.breadcrumbs = append(s.breadcrumbs, Breadcrumb{
+		Timestamp: time.Now(),
+		Category:  category,
+		Message:   message,
+	})
+}
+
+func newEvent(ctx context.Context, err error) Event {
+	event := Event{
+		Timestamp: time.Now(),
+		Message:   err.Error(),
+		Type:      rootType(err),
+	}
+
+	if chain := utils.ErrorChain(err); len(chain) > 1 {
+		event.Causes = chain[1:]
+	}
+
+	// Only panics carry the stack of where they happened. The stack of the
+	// reporting goroutine would only show the error handler, so other errors
+	// have none and are grouped by type and message.
+	var panicErr *utils.PanicError
+	if errors.As(err, &panicErr) {
+		event.Panic = true
+		event.Stack = string(panicErr.Stack)
+	}
+
+	if s, ok := ctx.Value(scopeContextKey).(*scope); ok {
+		r := s.request
+		event.Request = &Request{
+			Method:    r.Method,
+			URL:       r.URL.RequestURI(),
+			ClientIP:  utils.ClientIP(r),
+			UserAgent: r.UserAgent(),
+		}
+
+		s.mu.Lock()
+		event.Breadcrumbs = append([]Breadcrumb(nil), s.breadcrumbs...)
+		s.mu.Unlock()
+	}
+
+	route := utils.RouteFromContext(ctx)
+	requestID := utils.RequestIDFromContext(ctx)
+	principal, hasPrincipal := auth.PrincipalFromContext(ctx)
+
+	if event.Request == nil && (route != "" || requestID != "") {
+		event.Request = &Request{}
+	}
+	if event.Request != nil {
+		event.Request.Route = route
+		event.Request.RequestID = requestID
+		if hasPrincipal && !principal.IsAnonymous() {
+			event.Request.Principal = principal.Subject
+		}
+	}
+
+	if sc, ok := tracing.SpanContextFromContext(ctx); ok {
+		event.TraceID = sc.TraceID.String()
+	}
+
+	event.Fingerprint = fingerprint(event, route)
+
+	return event
+}
+
+// rootType returns the type of the innermost error, e.g. *pq.Error, which
+// groups better than the *fmt.wrapError around it
+func rootType(err error) string {
+	for {
+		next := errors.Unwrap(err)
+		if next == nil {
+			return fmt.Sprintf("%T", err)
+		}
+		err = next
+	}
+}
+
+// volatile matches the parts of messages that differ between occurrences of
+// the same error: UUIDs, hex IDs and numbers
+var volatile = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}|\b[0-9a-fA-F]{16,}\b|\d+`)
+
+// fingerprint groups events by error type, route and message with IDs and
+// numbers removed
+func fingerprint(event Event, route string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s", event.Type, route, volatile.ReplaceAllString(event.Message, "?"))
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
@@ -0,0 +1,156 @@
+package reporting
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/leninner/shared/utils"
+)
+
+// Reporter sends events to an error tracker. Report runs in the goroutine
+// that hit the error, often while a response is pending, so it must not
+// block on the network.
+type Reporter interface {
+	Report(event Event)
+}
+
+// WriterReporter writes each event as a line of JSON through a
+// utils.JSONLinesWriter
+type WriterReporter struct {
+	*utils.JSONLinesWriter
+}
+
+func NewWriterReporter(w io.Writer) *WriterReporter {
+	return &WriterReporter{utils.NewJSONLinesWriter(w, 0)}
+}
+
+// NewFileReporter appends events to the file at path, creating it if needed
+func NewFileReporter(path string) (*WriterReporter, error) {
+	jw, err := utils.OpenJSONLinesFile(path, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WriterReporter{jw}, nil
+}
+
+func (rep *WriterReporter) Report(event Event) {
+	rep.Write(event)
+}
+
+type Options struct {
+	Service     string
+	Environment string
+	// Window is how long further events with the same fingerprint are only
+	// counted after one is reported. Zero reports every event.
+	Window time.Duration
+}
+
+type client struct {
+	reporter Reporter
+	opts     Options
+
+	mu   sync.Mutex
+	seen map[string]*occurrence
+}
+
+type occurrence struct {
+	reported   time.Time
+	suppressed int
+}
+
+var (
+	clientMu      sync.RWMutex
+	defaultClient *client
+)
+
+// SetReporter installs the reporter used by Capture and registers it as the
+// utils panic reporter, so panics recovered by middleware.RecoverPanic and
+// utils.Background are reported too. A nil reporter disables reporting.
+func SetReporter(reporter Reporter, opts Options) {
+	clientMu.Lock()
+	defer clientMu.Unlock()
+
+	if reporter == nil {
+		defaultClient = nil
+		utils.SetPanicReporter(nil)
+		return
+	}
+
+	defaultClient = &client{
+		reporter: reporter,
+		opts:     opts,
+		seen:     make(map[string]*occurrence),
+	}
+
+	utils.SetPanicReporter(utils.PanicReporterFunc(func(ctx context.Context, err *utils.PanicError) {
+		Capture(ctx, err)
+	}))
+}
+
+// Capture reports err with the request details, breadcrumbs and trace found
+// in ctx
+func Capture(ctx context.Context, err error) {
+	clientMu.RLock()
+	c := defaultClient
+	clientMu.RUnlock()
+
+	if c == nil || err == nil {
+		return
+	}
+
+	event := newEvent(ctx, err)
+	event.Service = c.opts.Service
+	event.Environment = c.opts.Environment
+
+	suppressed, ok := c.allow(event.Fingerprint, event.Timestamp)
+	if !ok {
+		return
+	}
+	event.Suppressed = suppressed
+
+	c.reporter.Report(event)
+}
+
+// CaptureRequest reports err for a request that didn't go through
+// RecoverPanic, so its details are taken from r
+func CaptureRequest(r *http.Request, err error) {
+	Capture(ContextWithRequest(r).Context(), err)
+}
+
+// allow reports whether an event with the fingerprint should be sent now,
+// and how many were suppressed before it
+func (c *client) allow(fingerprint string, now time.Time) (int, bool) {
+	if c.opts.Window <= 0 {
+		return 0, true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	o, ok := c.seen[fingerprint]
+	if ok && now.Sub(o.reported) < c.opts.Window {
+		o.suppressed++
+		return 0, false
+	}
+
+	suppressed := 0
+	if ok {
+		suppressed = o.suppressed
+	}
+
+	// Forget fingerprints that went quiet so the map doesn't grow forever
+	if len(c.seen) > 1000 {
+		for key, other := range c.seen {
+			if now.Sub(other.reported) >= c.opts.Window {
+				delete(c.seen, key)
+			}
+		}
+	}
+
+	c.seen[fingerprint] = &occurrence{reported: now}
+	return suppressed, true
+}
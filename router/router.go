@@ -0,0 +1,101 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/leninner/shared/exception"
+	"github.com/leninner/shared/middleware"
+	"github.com/leninner/shared/utils"
+)
+
+// Router wraps httprouter with route groups and middleware chains. Unmatched
+// paths and methods are answered through the exception package.
+type Router struct {
+	mux     *httprouter.Router
+	root    *Group
+	global  middleware.Chain
+	once    sync.Once
+	handler http.Handler
+}
+
+func New() *Router {
+	mux := httprouter.New()
+	mux.NotFound = http.HandlerFunc(exception.NotFoundResponse)
+	mux.MethodNotAllowed = http.HandlerFunc(exception.MethodNotAllowedResponse)
+
+	return &Router{
+		mux:  mux,
+		root: &Group{mux: mux},
+	}
+}
+
+// Use adds middleware that runs for every request, including requests that
+// don't match any route. It must be called before the router serves traffic.
+func (rt *Router) Use(middlewares ...middleware.Middleware) {
+	rt.global = rt.global.Append(middlewares...)
+}
+
+func (rt *Router) Group(prefix string, middlewares ...middleware.Middleware) *Group {
+	return rt.root.Group(prefix, middlewares...)
+}
+
+func (rt *Router) Handle(method, path string, handler http.Handler) {
+	rt.root.Handle(method, path, handler)
+}
+
+func (rt *Router) HandlerFunc(method, path string, fn http.HandlerFunc) {
+	rt.root.Handle(method, path, fn)
+}
+
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rt.once.Do(func() {
+		rt.handler = rt.global.Then(rt.mux)
+	})
+
+	rt.handler.ServeHTTP(w, utils.ContextInitRoute(r))
+}
+
+// Group registers routes under a common path prefix with its own middleware
+type Group struct {
+	mux    *httprouter.Router
+	prefix string
+	chain  middleware.Chain
+}
+
+// Use adds middleware to the routes registered on the group afterwards
+func (g *Group) Use(middlewares ...middleware.Middleware) {
+	g.chain = g.chain.Append(middlewares...)
+}
+
+func (g *Group) Group(prefix string, middlewares ...middleware.Middleware) *Group {
+	return &Group{
+		mux:    g.mux,
+		prefix: joinPath(g.prefix, prefix),
+		chain:  g.chain.Append(middlewares...),
+	}
+}
+
+func (g *Group) Handle(method, path string, handler http.Handler) {
+	pattern := joinPath(g.prefix, path)
+	handler = g.chain.Then(handler)
+
+	g.mux.Handler(method, pattern, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r = utils.ContextSetRoute(r, pattern)
+		handler.ServeHTTP(w, r)
+	}))
+}
+
+func (g *Group) HandlerFunc(method, path string, fn http.HandlerFunc) {
+	g.Handle(method, path, fn)
+}
+
+func joinPath(prefix, path string) string {
+	if prefix == "" {
+		return path
+	}
+
+	return strings.TrimSuffix(prefix, "/") + "/" + strings.TrimPrefix(path, "/")
+}
@@ -0,0 +1,209 @@
+package router
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/leninner/shared/exception"
+	"github.com/leninner/shared/i18n"
+	"github.com/leninner/shared/middleware"
+)
+
+type contextKey string
+
+const versionContextKey = contextKey("apiVersion")
+
+// Deprecation marks an API version as deprecated. Responses for it carry the
+// Deprecation header, plus Sunset and a deprecation Link when set.
+type Deprecation struct {
+	Since  time.Time
+	Sunset time.Time
+	Link   string
+}
+
+// Versioning resolves the API version of a request from, in order, a path
+// prefix such as /v2/orders, a version parameter on the Accept media type
+// such as application/json;version=2, or a header such as API-Version: 2.
+type Versioning struct {
+	Supported    []string
+	Default      string
+	Header       string
+	Deprecations map[string]Deprecation
+	// StripPrefix removes the version prefix from the path before routing,
+	// so /v2/orders/1 matches a route registered as /orders/:id. It only
+	// applies when Middleware runs before the router, through Router.Use.
+	StripPrefix bool
+}
+
+func NewVersioning(defaultVersion string, supported ...string) *Versioning {
+	return &Versioning{
+		Supported:    supported,
+		Default:      defaultVersion,
+		Header:       "API-Version",
+		Deprecations: make(map[string]Deprecation),
+		StripPrefix:  true,
+	}
+}
+
+func (v *Versioning) Deprecate(version string, deprecation Deprecation) *Versioning {
+	v.Deprecations[version] = deprecation
+	return v
+}
+
+func (v *Versioning) Resolve(r *http.Request) (string, error) {
+	version := versionFromPath(r.URL.Path)
+
+	if version == "" {
+		version = versionFromAccept(r.Header.Get("Accept"))
+	}
+
+	if version == "" && v.Header != "" {
+		version = strings.TrimPrefix(strings.TrimSpace(r.Header.Get(v.Header)), "v")
+	}
+
+	if version == "" {
+		version = v.Default
+	}
+
+	if !slices.Contains(v.Supported, version) {
+		return "", i18n.NewError("errors.unsupported_api_version", i18n.Params{"version": version})
+	}
+
+	return version, nil
+}
+
+// Middleware stores the resolved version in the request context, echoes it
+// in the response and adds the deprecation headers
+func (v *Versioning) Middleware() middleware.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept")
+			if v.Header != "" {
+				w.Header().Add("Vary", v.Header)
+			}
+
+			version, err := v.Resolve(r)
+			if err != nil {
+				exception.BadRequestResponse(w, r, err)
+				return
+			}
+
+			if v.Header != "" {
+				w.Header().Set(v.Header, version)
+			}
+
+			if deprecation, ok := v.Deprecations[version]; ok {
+				if deprecation.Since.IsZero() {
+					w.Header().Set("Deprecation", "true")
+				} else {
+					w.Header().Set("Deprecation", "@"+strconv.FormatInt(deprecation.Since.Unix(), 10))
+				}
+				if !deprecation.Sunset.IsZero() {
+					w.Header().Set("Sunset", deprecation.Sunset.UTC().Format(http.TimeFormat))
+				}
+				if deprecation.Link != "" {
+					w.Header().Add("Link", fmt.Sprintf("<%s>; rel=\"deprecation\"", deprecation.Link))
+				}
+			}
+
+			if v.StripPrefix {
+				r = stripVersionPrefix(r)
+			}
+
+			ctx := context.WithValue(r.Context(), versionContextKey, version)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// VersionFromContext returns the version resolved by Versioning.Middleware
+func VersionFromContext(ctx context.Context) string {
+	version, _ := ctx.Value(versionContextKey).(string)
+	return version
+}
+
+// Dispatch serves each request with the handler registered for its resolved
+// version, so one route can serve several versions. With the versioning
+// middleware installed on the router, /v2/orders/1 reaches the route below
+// as /orders/1 with version 2:
+//
+//	rt.Use(versioning.Middleware())
+//	rt.Handle(http.MethodGet, "/orders/:id", router.Dispatch(map[string]http.Handler{
+//		"1": showOrderV1,
+//		"2": showOrderV2,
+//	}))
+func Dispatch(handlers map[string]http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler, ok := handlers[VersionFromContext(r.Context())]
+		if !ok {
+			exception.NotFoundResponse(w, r)
+			return
+		}
+
+		handler.ServeHTTP(w, r)
+	})
+}
+
+func versionFromPath(path string) string {
+	segment, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+
+	version, ok := strings.CutPrefix(segment, "v")
+	if !ok || version == "" {
+		return ""
+	}
+
+	if _, err := strconv.Atoi(version); err != nil {
+		return ""
+	}
+
+	return version
+}
+
+// stripVersionPrefix returns r with the /vN prefix removed from its path,
+// or r itself when the path has none
+func stripVersionPrefix(r *http.Request) *http.Request {
+	version := versionFromPath(r.URL.Path)
+	if version == "" {
+		return r
+	}
+
+	prefix := "/v" + version
+	path, ok := strings.CutPrefix(r.URL.Path, prefix)
+	if !ok {
+		return r
+	}
+
+	r2 := new(http.Request)
+	*r2 = *r
+	r2.URL = new(url.URL)
+	*r2.URL = *r.URL
+	r2.URL.Path = cmp.Or(path, "/")
+	if r.URL.RawPath != "" {
+		r2.URL.RawPath = cmp.Or(strings.TrimPrefix(r.URL.RawPath, prefix), "/")
+	}
+
+	return r2
+}
+
+func versionFromAccept(accept string) string {
+	for _, mediaRange := range strings.Split(accept, ",") {
+		_, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+		if err != nil {
+			continue
+		}
+
+		if version, ok := params["version"]; ok {
+			return strings.TrimPrefix(version, "v")
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,57 @@
+package router
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestVersioningDispatchesPathPrefixVersions(t *testing.T) {
+	versioning := NewVersioning("1", "1", "2")
+
+	rt := New()
+	rt.Use(versioning.Middleware())
+	rt.Handle(http.MethodGet, "/orders/:id", Dispatch(map[string]http.Handler{
+		"1": http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, "v1 "+r.URL.Path)
+		}),
+		"2": http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, "v2 "+r.URL.Path)
+		}),
+	}))
+
+	tests := []struct {
+		name   string
+		path   string
+		header string
+		status int
+		body   string
+	}{
+		{name: "path prefix", path: "/v2/orders/1", status: http.StatusOK, body: "v2 /orders/1"},
+		{name: "path prefix of default", path: "/v1/orders/1", status: http.StatusOK, body: "v1 /orders/1"},
+		{name: "header", path: "/orders/1", header: "2", status: http.StatusOK, body: "v2 /orders/1"},
+		{name: "default", path: "/orders/1", status: http.StatusOK, body: "v1 /orders/1"},
+		{name: "unsupported prefix", path: "/v3/orders/1", status: http.StatusBadRequest},
+		{name: "unknown route", path: "/v2/customers/1", status: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.header != "" {
+				r.Header.Set("API-Version", tt.header)
+			}
+			w := httptest.NewRecorder()
+
+			rt.ServeHTTP(w, r)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			if tt.body != "" && w.Body.String() != tt.body {
+				t.Errorf("body = %q, want %q", w.Body.String(), tt.body)
+			}
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"log"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -14,9 +15,20 @@ import (
 	"time"
 
 	"github.com/leninner/shared/config"
+	"github.com/leninner/shared/exception"
 )
 
 func Serve(app *config.Application, handler http.Handler) error {
+	return ServeWithAdmin(app, handler, nil)
+}
+
+// ServeWithAdmin also serves the admin handler (metrics, mode switch, ...) on
+// Config.AdminPort, so those endpoints are never exposed on the public port.
+// Both servers shut down together, and the service doesn't start, or stops,
+// when the admin server can't listen.
+func ServeWithAdmin(app *config.Application, handler http.Handler, admin http.Handler) error {
+	exception.SetLogger(app.Logger)
+
 	srv := &http.Server{
 		Addr:         fmt.Sprintf(":%d", app.Config.Port),
 		Handler:      handler,
@@ -26,6 +38,22 @@ func Serve(app *config.Application, handler http.Handler) error {
 		ErrorLog:     slog.NewLogLogger(app.Logger.Handler(), slog.LevelError),
 	}
 
+	if admin != nil && app.Config.AdminPort == 0 {
+		app.Logger.Warn("admin server disabled, admin endpoints are not served", "admin_port", 0)
+	}
+
+	var adminSrv *http.Server
+	if admin != nil && app.Config.AdminPort != 0 {
+		adminSrv = &http.Server{
+			Addr:         fmt.Sprintf(":%d", app.Config.AdminPort),
+			Handler:      admin,
+			IdleTimeout:  time.Minute,
+			ReadTimeout:  5 * time.Second,
+			WriteTimeout: 30 * time.Second,
+			ErrorLog:     slog.NewLogLogger(app.Logger.Handler(), slog.LevelError),
+		}
+	}
+
 	shutdownError := make(chan error)
 
 	go func() {
@@ -39,17 +67,51 @@ func Serve(app *config.Application, handler http.Handler) error {
 		defer cancel()
 
 		app.WG.Wait()
+
+		if adminSrv != nil {
+			if err := adminSrv.Shutdown(ctx); err != nil {
+				app.Logger.Error("shutting down admin server", "error", err)
+			}
+		}
+
 		shutdownError <- srv.Shutdown(ctx)
 	}()
 
+	adminError := make(chan error, 1)
+
+	if adminSrv != nil {
+		// Listen before the public server starts so a taken port fails startup
+		ln, err := net.Listen("tcp", adminSrv.Addr)
+		if err != nil {
+			return fmt.Errorf("admin server: %w", err)
+		}
+
+		go func() {
+			app.Logger.Info("starting admin server", "addr", adminSrv.Addr)
+
+			err := adminSrv.Serve(ln)
+			if !errors.Is(err, http.ErrServerClosed) {
+				adminError <- fmt.Errorf("admin server: %w", err)
+				srv.Close()
+			}
+		}()
+	}
+
 	app.Logger.Info("starting server", "addr", srv.Addr, "env", app.Config.Env)
 
 	err := srv.ListenAndServe()
 	if !errors.Is(err, http.ErrServerClosed) {
+		if adminSrv != nil {
+			adminSrv.Close()
+		}
 		return err
 	}
 
-	err = <-shutdownError
+	select {
+	case err = <-adminError:
+		return err
+	case err = <-shutdownError:
+	}
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,187 @@
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	TraceparentHeader   = "Traceparent"
+	TracestateHeader    = "Tracestate"
+	TraceresponseHeader = "Traceresponse"
+
+	flagSampled = 0x01
+
+	maxTracestateMembers = 32
+)
+
+var ErrInvalidTraceparent = errors.New("invalid traceparent header")
+
+type TraceID [16]byte
+
+type SpanID [8]byte
+
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+func (t TraceID) IsValid() bool {
+	return t != TraceID{}
+}
+
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+func (s SpanID) IsValid() bool {
+	return s != SpanID{}
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	rand.Read(id[:])
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	rand.Read(id[:])
+	return id
+}
+
+// SpanContext is the part of a span that crosses process boundaries in the
+// traceparent and tracestate headers
+type SpanContext struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	Flags      byte
+	TraceState string
+	// Remote is true when the span context was received from another service
+	Remote bool
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+func (sc SpanContext) Sampled() bool {
+	return sc.Flags&flagSampled != 0
+}
+
+// Traceparent formats the span context as a version 00 traceparent value
+func (sc SpanContext) Traceparent() string {
+	return fmt.Sprintf("00-%s-%s-%02x", sc.TraceID, sc.SpanID, sc.Flags)
+}
+
+// ParseTraceparent parses a traceparent header value such as
+// 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01. Versions above 00
+// are accepted as long as their first four fields are well formed.
+func ParseTraceparent(value string) (SpanContext, error) {
+	var sc SpanContext
+
+	fields := strings.Split(strings.TrimSpace(value), "-")
+	if len(fields) < 4 {
+		return sc, ErrInvalidTraceparent
+	}
+
+	version, traceID, spanID, flags := fields[0], fields[1], fields[2], fields[3]
+
+	if len(version) != 2 || !isLowerHex(version) || version == "ff" {
+		return sc, ErrInvalidTraceparent
+	}
+	if version == "00" && len(fields) != 4 {
+		return sc, ErrInvalidTraceparent
+	}
+
+	if len(traceID) != 32 || !isLowerHex(traceID) || len(spanID) != 16 || !isLowerHex(spanID) || len(flags) != 2 || !isLowerHex(flags) {
+		return sc, ErrInvalidTraceparent
+	}
+
+	hex.Decode(sc.TraceID[:], []byte(traceID))
+	hex.Decode(sc.SpanID[:], []byte(spanID))
+
+	var flagBytes [1]byte
+	hex.Decode(flagBytes[:], []byte(flags))
+	sc.Flags = flagBytes[0]
+
+	if !sc.IsValid() {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+
+	sc.Remote = true
+	return sc, nil
+}
+
+// Extract reads the span context propagated by the caller, if any. An
+// invalid tracestate is dropped without discarding the traceparent.
+func Extract(h http.Header) (SpanContext, bool) {
+	values := h.Values(TraceparentHeader)
+	if len(values) != 1 {
+		return SpanContext{}, false
+	}
+
+	sc, err := ParseTraceparent(values[0])
+	if err != nil {
+		return SpanContext{}, false
+	}
+
+	sc.TraceState = parseTracestate(h.Values(TracestateHeader))
+
+	return sc, true
+}
+
+// Inject writes the span context into the outgoing headers
+func Inject(sc SpanContext, h http.Header) {
+	if !sc.IsValid() {
+		return
+	}
+
+	h.Set(TraceparentHeader, sc.Traceparent())
+
+	if sc.TraceState != "" {
+		h.Set(TracestateHeader, sc.TraceState)
+	} else {
+		h.Del(TracestateHeader)
+	}
+}
+
+// parseTracestate joins the tracestate headers into one list and drops it if
+// any member is malformed or there are too many of them
+func parseTracestate(values []string) string {
+	var members []string
+
+	for _, value := range values {
+		for _, member := range strings.Split(value, ",") {
+			member = strings.TrimSpace(member)
+			if member == "" {
+				continue
+			}
+
+			key, val, ok := strings.Cut(member, "=")
+			if !ok || key == "" || val == "" || strings.ContainsAny(key, " \t") || strings.ContainsAny(val, ",=") {
+				return ""
+			}
+
+			members = append(members, member)
+		}
+	}
+
+	if len(members) > maxTracestateMembers {
+		return ""
+	}
+
+	return strings.Join(members, ",")
+}
+
+func isLowerHex(s string) bool {
+	for _, c := range s {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
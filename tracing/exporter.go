@@ -0,0 +1,60 @@
+package tracing
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/leninner/shared/config"
+	"github.com/leninner/shared/utils"
+)
+
+// Exporter receives finished, sampled spans. ExportSpan is called on the
+// request path, so implementations that talk to a collector should buffer.
+type Exporter interface {
+	ExportSpan(span SpanData)
+}
+
+// WriterExporter writes each span as a line of JSON through a
+// utils.JSONLinesWriter
+type WriterExporter struct {
+	*utils.JSONLinesWriter
+}
+
+func NewWriterExporter(w io.Writer) *WriterExporter {
+	return &WriterExporter{utils.NewJSONLinesWriter(w, 0)}
+}
+
+// NewFileExporter appends spans to the file at path, creating it if needed
+func NewFileExporter(path string) (*WriterExporter, error) {
+	jw, err := utils.OpenJSONLinesFile(path, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WriterExporter{jw}, nil
+}
+
+func (e *WriterExporter) ExportSpan(span SpanData) {
+	e.Write(span)
+}
+
+// NewExporter builds the exporter selected by cfg.Exporter: stdout, file or
+// none. It returns a nil exporter for none, which records no spans while
+// still propagating trace context.
+func NewExporter(cfg config.TracingConfig) (Exporter, error) {
+	switch cfg.Exporter {
+	case "", "none":
+		return nil, nil
+	case "stdout":
+		return NewWriterExporter(os.Stdout), nil
+	case "file":
+		exporter, err := NewFileExporter(cfg.File)
+		if err != nil {
+			return nil, err
+		}
+		return exporter, nil
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", cfg.Exporter)
+	}
+}
@@ -0,0 +1,38 @@
+package tracing
+
+import (
+	"context"
+	"log/slog"
+)
+
+// LogHandler adds trace_id and span_id to every record logged with a
+// context that carries a span:
+//
+//	logger := slog.New(tracing.NewLogHandler(slog.NewJSONHandler(os.Stdout, nil)))
+//	logger.InfoContext(r.Context(), "order created")
+type LogHandler struct {
+	slog.Handler
+}
+
+func NewLogHandler(h slog.Handler) *LogHandler {
+	return &LogHandler{Handler: h}
+}
+
+func (h *LogHandler) Handle(ctx context.Context, record slog.Record) error {
+	if sc, ok := SpanContextFromContext(ctx); ok {
+		record.AddAttrs(
+			slog.String("trace_id", sc.TraceID.String()),
+			slog.String("span_id", sc.SpanID.String()),
+		)
+	}
+
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *LogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &LogHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *LogHandler) WithGroup(name string) slog.Handler {
+	return &LogHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,210 @@
+package tracing
+
+import (
+	"context"
+	"maps"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/leninner/shared/config"
+)
+
+type contextKey string
+
+const (
+	spanContextKey   = contextKey("span")
+	remoteContextKey = contextKey("remoteSpanContext")
+)
+
+type SpanKind string
+
+const (
+	SpanKindServer   SpanKind = "server"
+	SpanKindClient   SpanKind = "client"
+	SpanKindInternal SpanKind = "internal"
+	SpanKindProducer SpanKind = "producer"
+	SpanKindConsumer SpanKind = "consumer"
+)
+
+// SpanData is a finished span as handed to the exporter
+type SpanData struct {
+	Service    string         `json:"service"`
+	Name       string         `json:"name"`
+	Kind       SpanKind       `json:"kind"`
+	TraceID    string         `json:"trace_id"`
+	SpanID     string         `json:"span_id"`
+	ParentID   string         `json:"parent_id,omitempty"`
+	Start      time.Time      `json:"start"`
+	End        time.Time      `json:"end"`
+	DurationMS float64        `json:"duration_ms"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+	Error      string         `json:"error,omitempty"`
+}
+
+type Span struct {
+	tracer   *Tracer
+	sc       SpanContext
+	parentID SpanID
+	kind     SpanKind
+	start    time.Time
+
+	mu         sync.Mutex
+	name       string
+	attributes map[string]any
+	err        error
+	ended      bool
+}
+
+func (s *Span) SpanContext() SpanContext {
+	return s.sc
+}
+
+// SetName replaces the span name, for example once the route pattern is
+// known. Like the other setters, it does nothing once the span has ended.
+func (s *Span) SetName(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ended {
+		return
+	}
+	s.name = name
+}
+
+func (s *Span) SetAttribute(key string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ended {
+		return
+	}
+	if s.attributes == nil {
+		s.attributes = make(map[string]any)
+	}
+	s.attributes[key] = value
+}
+
+func (s *Span) SetError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ended {
+		return
+	}
+	s.err = err
+}
+
+// End finishes the span and exports it if it was sampled. Only the first
+// call has any effect.
+func (s *Span) End() {
+	end := time.Now()
+
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+
+	data := SpanData{
+		Service:    s.tracer.service,
+		Name:       s.name,
+		Kind:       s.kind,
+		TraceID:    s.sc.TraceID.String(),
+		SpanID:     s.sc.SpanID.String(),
+		Start:      s.start,
+		End:        end,
+		DurationMS: float64(end.Sub(s.start).Microseconds()) / 1000,
+		Attributes: maps.Clone(s.attributes),
+	}
+	if s.parentID.IsValid() {
+		data.ParentID = s.parentID.String()
+	}
+	if s.err != nil {
+		data.Error = s.err.Error()
+	}
+	s.mu.Unlock()
+
+	if s.sc.Sampled() && s.tracer.exporter != nil {
+		s.tracer.exporter.ExportSpan(data)
+	}
+}
+
+// Tracer starts spans for one service and hands finished spans to its
+// exporter
+type Tracer struct {
+	service  string
+	exporter Exporter
+	// sampleRatio is the fraction of new traces recorded. Traces started by
+	// a caller follow the caller's sampling decision.
+	sampleRatio float64
+}
+
+func NewTracer(service string, exporter Exporter, cfg config.TracingConfig) *Tracer {
+	return &Tracer{
+		service:     service,
+		exporter:    exporter,
+		sampleRatio: cfg.SampleRatio,
+	}
+}
+
+// Start starts a span as a child of the span in ctx, or of the remote span
+// context stored by ContextWithRemoteSpanContext, or as the root of a new
+// trace. The returned context carries the new span.
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	span := &Span{
+		tracer: t,
+		kind:   kind,
+		name:   name,
+		start:  time.Now(),
+	}
+
+	parent, ok := parentSpanContext(ctx)
+	if ok {
+		span.sc = SpanContext{
+			TraceID:    parent.TraceID,
+			Flags:      parent.Flags,
+			TraceState: parent.TraceState,
+		}
+		span.parentID = parent.SpanID
+	} else {
+		span.sc = SpanContext{TraceID: newTraceID()}
+		if t.sampleRatio >= 1 || rand.Float64() < t.sampleRatio {
+			span.sc.Flags = flagSampled
+		}
+	}
+	span.sc.SpanID = newSpanID()
+
+	return context.WithValue(ctx, spanContextKey, span), span
+}
+
+func parentSpanContext(ctx context.Context) (SpanContext, bool) {
+	if span := SpanFromContext(ctx); span != nil {
+		return span.sc, true
+	}
+
+	if sc, ok := ctx.Value(remoteContextKey).(SpanContext); ok && sc.IsValid() {
+		return sc, true
+	}
+
+	return SpanContext{}, false
+}
+
+// ContextWithRemoteSpanContext stores a span context received from another
+// service so the next span started from ctx continues its trace
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteContextKey, sc)
+}
+
+// SpanFromContext returns the current span, or nil if there is none
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanContextKey).(*Span)
+	return span
+}
+
+// SpanContextFromContext returns the span context of the current span, or
+// the remote span context if no span was started yet
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+	return parentSpanContext(ctx)
+}
@@ -0,0 +1,47 @@
+package tracing
+
+import (
+	"net/http"
+)
+
+// Transport propagates the trace context of each request's context to the
+// called service and records a client span for the call:
+//
+//	client := &http.Client{Transport: tracing.NewTransport(tracer, nil)}
+//	req, _ := http.NewRequestWithContext(r.Context(), http.MethodPost, paymentURL, body)
+//	resp, err := client.Do(req)
+type Transport struct {
+	Base   http.RoundTripper
+	Tracer *Tracer
+}
+
+// NewTransport wraps base, or http.DefaultTransport if base is nil
+func NewTransport(tracer *Tracer, base http.RoundTripper) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &Transport{Base: base, Tracer: tracer}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := t.Tracer.Start(req.Context(), req.Method+" "+req.URL.Host, SpanKindClient)
+	defer span.End()
+
+	span.SetAttribute("http.method", req.Method)
+	span.SetAttribute("http.url", req.URL.Redacted())
+
+	// RoundTrippers must not modify the caller's request
+	req = req.Clone(ctx)
+	Inject(span.SpanContext(), req.Header)
+
+	resp, err := t.Base.RoundTrip(req)
+	if err != nil {
+		span.SetError(err)
+		return nil, err
+	}
+
+	span.SetAttribute("http.status_code", resp.StatusCode)
+
+	return resp, nil
+}
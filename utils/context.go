@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+)
+
+type contextKey string
+
+const routeContextKey = contextKey("route")
+
+type routeInfo struct {
+	pattern string
+}
+
+// ContextInitRoute prepares the request context to receive the matched route
+// pattern. Middleware running before the router can read the pattern with
+// ContextGetRoute once the next handler returns.
+func ContextInitRoute(r *http.Request) *http.Request {
+	if _, ok := r.Context().Value(routeContextKey).(*routeInfo); ok {
+		return r
+	}
+
+	ctx := context.WithValue(r.Context(), routeContextKey, &routeInfo{})
+	return r.WithContext(ctx)
+}
+
+func ContextSetRoute(r *http.Request, pattern string) *http.Request {
+	if info, ok := r.Context().Value(routeContextKey).(*routeInfo); ok {
+		info.pattern = pattern
+		return r
+	}
+
+	ctx := context.WithValue(r.Context(), routeContextKey, &routeInfo{pattern: pattern})
+	return r.WithContext(ctx)
+}
+
+func ContextGetRoute(r *http.Request) string {
+	return RouteFromContext(r.Context())
+}
+
+// RouteFromContext returns the matched route pattern, such as
+// "/v1/orders/:id", or an empty string if no route matched
+func RouteFromContext(ctx context.Context) string {
+	if info, ok := ctx.Value(routeContextKey).(*routeInfo); ok {
+		return info.pattern
+	}
+
+	return ""
+}
+
+const requestIDContextKey = contextKey("requestID")
+
+func ContextSetRequestID(r *http.Request, id string) *http.Request {
+	ctx := context.WithValue(r.Context(), requestIDContextKey, id)
+	return r.WithContext(ctx)
+}
+
+func ContextGetRequestID(r *http.Request) string {
+	return RequestIDFromContext(r.Context())
+}
+
+// RequestIDFromContext returns the ID assigned by middleware.RequestID, or an
+// empty string outside a request
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey).(string)
+	return id
+}
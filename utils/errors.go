@@ -0,0 +1,28 @@
+package utils
+
+// ErrorChain returns the messages of err and every error it wraps, depth
+// first, including each branch of errors.Join
+func ErrorChain(err error) []string {
+	var chain []string
+
+	var walk func(error)
+	walk = func(err error) {
+		if err == nil {
+			return
+		}
+
+		chain = append(chain, err.Error())
+
+		switch e := err.(type) {
+		case interface{ Unwrap() error }:
+			walk(e.Unwrap())
+		case interface{ Unwrap() []error }:
+			for _, inner := range e.Unwrap() {
+				walk(inner)
+			}
+		}
+	}
+
+	walk(err)
+	return chain
+}
@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ComputeETag returns a quoted entity tag for the body. Weak tags are
+// prefixed with W/ and only promise semantic equivalence.
+func ComputeETag(body []byte, weak bool) string {
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	if weak {
+		return "W/" + etag
+	}
+
+	return etag
+}
+
+// VersionETag returns a strong entity tag for an entity version, suitable for
+// optimistic concurrency with If-Match
+func VersionETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// SetETag sets the ETag header and reports whether the request's
+// If-None-Match header already matches it, in which case the handler should
+// answer with NotModified instead of the body
+func SetETag(w http.ResponseWriter, r *http.Request, etag string) bool {
+	w.Header().Set("ETag", etag)
+
+	return IfNoneMatch(r, etag)
+}
+
+// NotModified writes a 304 response, dropping headers that only describe the
+// omitted body
+func NotModified(w http.ResponseWriter) {
+	h := w.Header()
+	delete(h, "Content-Type")
+	delete(h, "Content-Length")
+	delete(h, "Content-Encoding")
+
+	w.WriteHeader(http.StatusNotModified)
+}
+
+// IfNoneMatch reports whether the If-None-Match header matches the entity tag
+// using weak comparison
+func IfNoneMatch(r *http.Request, etag string) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+
+	return matchETag(header, etag, false)
+}
+
+// IfMatch reports whether the If-Match header allows an update of the entity
+// with the given tag, using strong comparison. A missing header matches so
+// clients that don't send one keep working; use HasIfMatch to require it.
+func IfMatch(r *http.Request, etag string) bool {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return true
+	}
+
+	return matchETag(header, etag, true)
+}
+
+func HasIfMatch(r *http.Request) bool {
+	return r.Header.Get("If-Match") != ""
+}
+
+func matchETag(header, etag string, strong bool) bool {
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+
+	if strong && strings.HasPrefix(etag, "W/") {
+		return false
+	}
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+
+		if strong {
+			if candidate == etag {
+				return true
+			}
+			continue
+		}
+
+		if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -1,11 +1,12 @@
 package utils
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"io"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -14,6 +15,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/julienschmidt/httprouter"
+	"github.com/leninner/shared/i18n"
 	"github.com/leninner/shared/utils/validator"
 )
 
@@ -59,6 +61,9 @@ func WriteJSON(w http.ResponseWriter, status int, data Envelope, headers http.He
 	return nil
 }
 
+// ReadJSON decodes the request body into target. Errors about the body are
+// *i18n.Error values, which exception.BadRequestResponse answers in the
+// client's language.
 func ReadJSON(w http.ResponseWriter, r *http.Request, target any) error {
 	// Limit the request body to 1MB
 	r.Body = http.MaxBytesReader(w, r.Body, 1_048_576)
@@ -76,26 +81,26 @@ func ReadJSON(w http.ResponseWriter, r *http.Request, target any) error {
 
 		switch {
 		case errors.As(err, &syntaxError):
-			return fmt.Errorf("body contains badly-formed JSON (at character %d)", syntaxError.Offset)
+			return i18n.NewError("errors.body_badly_formed_at", i18n.Params{"offset": syntaxError.Offset})
 
 		case errors.Is(err, io.ErrUnexpectedEOF):
-			return errors.New("body contains badly-formed JSON")
+			return i18n.NewError("errors.body_badly_formed", nil)
 
 		case errors.As(err, &unmarshalTypeError):
 			if unmarshalTypeError.Field != "" {
-				return fmt.Errorf("body contains incorrect JSON type for field %q", unmarshalTypeError.Field)
+				return i18n.NewError("errors.body_incorrect_type_field", i18n.Params{"field": unmarshalTypeError.Field})
 			}
-			return fmt.Errorf("body contains incorrect JSON type (at character %d)", unmarshalTypeError.Offset)
+			return i18n.NewError("errors.body_incorrect_type_at", i18n.Params{"offset": unmarshalTypeError.Offset})
 
 		case errors.Is(err, io.EOF):
-			return errors.New("body must not be empty")
+			return i18n.NewError("errors.body_empty", nil)
 
 		case strings.HasPrefix(err.Error(), "json: unknown field "):
 			fieldName := strings.TrimPrefix(err.Error(), "json: unknown field ")
-			return fmt.Errorf("body contains unknown key %s", fieldName)
+			return i18n.NewError("errors.body_unknown_key", i18n.Params{"key": fieldName})
 
 		case errors.As(err, &maxBytesError):
-			return fmt.Errorf("body must not be larger than %d bytes", maxBytesError.Limit)
+			return i18n.NewError("errors.body_too_large", i18n.Params{"limit": maxBytesError.Limit})
 
 		case errors.As(err, &invalidUnmarshalError):
 			panic(err)
@@ -107,7 +112,7 @@ func ReadJSON(w http.ResponseWriter, r *http.Request, target any) error {
 
 	err = dec.Decode(&struct{}{})
 	if !errors.Is(err, io.EOF) {
-		return errors.New("body must only contain a single JSON value")
+		return i18n.NewError("errors.body_multiple_values", nil)
 	}
 
 	return nil
@@ -162,7 +167,7 @@ func ReadInt(qs url.Values, key string, defaultValue int, v *validator.Validator
 	// validator instance and return the default value.
 	i, err := strconv.Atoi(s)
 	if err != nil {
-		v.AddError(key, "must be an integer value")
+		v.AddError(key, "validation.integer")
 		return defaultValue
 	}
 
@@ -170,7 +175,28 @@ func ReadInt(qs url.Values, key string, defaultValue int, v *validator.Validator
 	return i
 }
 
+// ClientIP returns the address of the connection's peer, without the port
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// Background runs callback in a goroutine tracked by wg. A panic is reported
+// through the panic reporter and logged to slog.Default().
 func Background(callback func(), wg *sync.WaitGroup) {
+	BackgroundWithLogger(nil, callback, wg)
+}
+
+// BackgroundWithLogger is Background logging panics to logger, usually the
+// service's config.Application.Logger; a nil logger uses slog.Default().
+func BackgroundWithLogger(logger *slog.Logger, callback func(), wg *sync.WaitGroup) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
 	wg.Add(1)
 
 	go func() {
@@ -179,7 +205,8 @@ func Background(callback func(), wg *sync.WaitGroup) {
 		defer func() {
 			pv := recover()
 			if pv != nil {
-				log.Println(fmt.Sprintf("%v", pv))
+				err := RecoveredPanic(context.Background(), pv)
+				logger.Error("background task panicked", "error", err.Error(), "stack", string(err.Stack))
 			}
 		}()
 
@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// JSONLinesWriter writes values as lines of JSON from its own goroutine, so
+// callers on the request path never wait on the file or stream. When the
+// buffer is full, values are dropped and counted instead of blocking.
+type JSONLinesWriter struct {
+	w     io.Writer
+	lines chan []byte
+	done  chan struct{}
+	// mu guards closed and the close of lines against concurrent sends
+	mu      sync.RWMutex
+	closed  bool
+	dropped atomic.Uint64
+}
+
+// NewJSONLinesWriter starts a writer buffering up to size lines, 1024 if
+// size is zero
+func NewJSONLinesWriter(w io.Writer, size int) *JSONLinesWriter {
+	if size <= 0 {
+		size = 1024
+	}
+
+	jw := &JSONLinesWriter{
+		w:     w,
+		lines: make(chan []byte, size),
+		done:  make(chan struct{}),
+	}
+	go jw.run()
+
+	return jw
+}
+
+// OpenJSONLinesFile appends lines to the file at path, creating it if needed
+func OpenJSONLinesFile(path string, size int) (*JSONLinesWriter, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewJSONLinesWriter(file, size), nil
+}
+
+// Write queues v. Values that fail to marshal or are written after Close
+// are dropped.
+func (jw *JSONLinesWriter) Write(v any) {
+	line, err := json.Marshal(v)
+	if err != nil {
+		jw.dropped.Add(1)
+		return
+	}
+
+	jw.mu.RLock()
+	defer jw.mu.RUnlock()
+
+	if jw.closed {
+		jw.dropped.Add(1)
+		return
+	}
+
+	select {
+	case jw.lines <- append(line, '\n'):
+	default:
+		jw.dropped.Add(1)
+	}
+}
+
+// Dropped returns the number of values that were not written
+func (jw *JSONLinesWriter) Dropped() uint64 {
+	return jw.dropped.Load()
+}
+
+// Close writes the queued lines and closes the underlying writer if it is an
+// io.Closer other than stdout or stderr. Values written after it are
+// dropped.
+func (jw *JSONLinesWriter) Close() error {
+	jw.mu.Lock()
+	if jw.closed {
+		jw.mu.Unlock()
+		<-jw.done
+		return nil
+	}
+	jw.closed = true
+	close(jw.lines)
+	jw.mu.Unlock()
+
+	<-jw.done
+
+	if jw.w == os.Stdout || jw.w == os.Stderr {
+		return nil
+	}
+	if closer, ok := jw.w.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func (jw *JSONLinesWriter) run() {
+	defer close(jw.done)
+
+	for line := range jw.lines {
+		if _, err := jw.w.Write(line); err != nil {
+			jw.dropped.Add(1)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+)
+
+// PanicError carries a recovered panic value and the stack of the goroutine
+// that panicked
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+func (e *PanicError) Unwrap() error {
+	if err, ok := e.Value.(error); ok {
+		return err
+	}
+	return nil
+}
+
+// PanicReporter is notified of every panic recovered by RecoverPanic and
+// Background, e.g. to forward it to an error tracker
+type PanicReporter interface {
+	ReportPanic(ctx context.Context, err *PanicError)
+}
+
+type PanicReporterFunc func(ctx context.Context, err *PanicError)
+
+func (f PanicReporterFunc) ReportPanic(ctx context.Context, err *PanicError) {
+	f(ctx, err)
+}
+
+var (
+	panicReporterMu sync.RWMutex
+	panicReporter   PanicReporter
+	panicsRecovered atomic.Uint64
+)
+
+func SetPanicReporter(reporter PanicReporter) {
+	panicReporterMu.Lock()
+	defer panicReporterMu.Unlock()
+	panicReporter = reporter
+}
+
+// PanicsRecovered returns the number of panics recovered since startup
+func PanicsRecovered() uint64 {
+	return panicsRecovered.Load()
+}
+
+// RecoveredPanic must be called from the deferred function that recovered
+// value, so the captured stack still points at the panic. A *PanicError
+// re-panicked from another goroutine keeps its original stack.
+func RecoveredPanic(ctx context.Context, value any) *PanicError {
+	err, ok := value.(*PanicError)
+	if !ok {
+		err = &PanicError{
+			Value: value,
+			Stack: debug.Stack(),
+		}
+	}
+
+	panicsRecovered.Add(1)
+
+	panicReporterMu.RLock()
+	reporter := panicReporter
+	panicReporterMu.RUnlock()
+
+	if reporter != nil {
+		reporter.ReportPanic(ctx, err)
+	}
+
+	return err
+}
@@ -5,6 +5,8 @@ import (
 	"regexp"
 	"slices"
 	"strings"
+
+	"github.com/leninner/shared/i18n"
 )
 
 var (
@@ -12,7 +14,33 @@ var (
 )
 
 type Validator struct {
+	// Errors holds the first message for each failed key
 	Errors map[string]string
+	// Details holds every failed rule for each key, in the order checked
+	Details map[string][]FieldError
+	locale  string
+}
+
+// FieldError is one failed rule. Code is machine readable, e.g. "required"
+// for the validation.required message, or "invalid" for plain text messages.
+type FieldError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type ValidationError struct {
+	Errors  map[string]string
+	Details map[string][]FieldError
+}
+
+func (e *ValidationError) Error() string {
+	keys := make([]string, 0, len(e.Errors))
+	for key := range e.Errors {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	return "validation failed: " + strings.Join(keys, ", ")
 }
 
 type ValidationEnvelope struct {
@@ -21,17 +49,61 @@ type ValidationEnvelope struct {
 }
 
 func New() *Validator {
-	return &Validator{Errors: make(map[string]string)}
+	return NewWithLocale(i18n.DefaultLocale)
+}
+
+// NewWithLocale returns a validator whose messages are translated to locale,
+// usually i18n.LocaleFromRequest(r). Messages can be catalog keys such as
+// "validation.required" or plain text, which is kept as is.
+func NewWithLocale(locale string) *Validator {
+	return &Validator{
+		Errors:  make(map[string]string),
+		Details: make(map[string][]FieldError),
+		locale:  locale,
+	}
 }
 
 func (v *Validator) Valid() bool {
 	return len(v.Errors) == 0
 }
 
+// Err returns the failed checks as a *ValidationError, or nil if every
+// check passed, so handlers can return them like any other error
+func (v *Validator) Err() error {
+	if v.Valid() {
+		return nil
+	}
+	return &ValidationError{Errors: v.Errors, Details: v.Details}
+}
+
 func (v *Validator) AddError(key, message string) {
+	v.addMessage(key, i18n.Message{Key: message})
+}
+
+func (v *Validator) addMessage(key string, message i18n.Message) {
+	text := i18n.Translate(v.locale, message.Key, message.Params)
+
 	if _, exists := v.Errors[key]; !exists {
-		v.Errors[key] = message
+		v.Errors[key] = text
+	}
+
+	if v.Details == nil {
+		v.Details = make(map[string][]FieldError)
+	}
+	v.Details[key] = append(v.Details[key], FieldError{Code: ruleCode(message.Key), Message: text})
+}
+
+// ruleCode derives the code of a failed rule from its message key
+func ruleCode(messageKey string) string {
+	if _, ok := i18n.Default.Lookup(i18n.DefaultLocale, messageKey); !ok {
+		return "invalid"
+	}
+
+	_, code, found := strings.Cut(messageKey, ".")
+	if !found {
+		return messageKey
 	}
+	return code
 }
 
 func (v *Validator) Check(ok bool, key, message string) {
@@ -40,6 +112,15 @@ func (v *Validator) Check(ok bool, key, message string) {
 	}
 }
 
+// CheckMessage is Check for messages with parameters:
+//
+//	v.CheckMessage(len(input.Name) <= 100, "name", i18n.Message{Key: "validation.max_length", Params: i18n.Params{"max": 100}})
+func (v *Validator) CheckMessage(ok bool, key string, message i18n.Message) {
+	if !ok {
+		v.addMessage(key, message)
+	}
+}
+
 func (v *Validator) Envelope(prefix string) *ValidationEnvelope {
 	return &ValidationEnvelope{
 		validator: v,
@@ -56,15 +137,23 @@ func (v *Validator) ArrayEnvelope(arrayKey string, index int) *ValidationEnvelop
 }
 
 func (env *ValidationEnvelope) Check(ok bool, key, message string) {
+	env.CheckMessage(ok, key, i18n.Message{Key: message})
+}
+
+func (env *ValidationEnvelope) CheckMessage(ok bool, key string, message i18n.Message) {
 	if !ok {
 		fullKey := key
 		if env.prefix != "" {
 			fullKey = strings.Join([]string{env.prefix, key}, ".")
 		}
-		env.validator.AddError(fullKey, message)
+		env.validator.addMessage(fullKey, message)
 	}
 }
 
+func (env *ValidationEnvelope) ArrayEnvelope(arrayKey string, index int) *ValidationEnvelope {
+	return env.Envelope(fmt.Sprintf("%s.%d", arrayKey, index))
+}
+
 func (env *ValidationEnvelope) Envelope(prefix string) *ValidationEnvelope {
 	newPrefix := prefix
 	if env.prefix != "" {